@@ -0,0 +1,89 @@
+package clock
+
+import "time"
+
+// Clock is the interface implemented by objects telling the time and scheduling timers.
+type Clock interface {
+	// Now will return the current time.
+	Now() time.Time
+	// Since will return the time elapsed since supplied instant.
+	Since(time.Time) time.Duration
+	// After will return a channel receiving the current time once supplied duration elapsed.
+	After(time.Duration) <-chan time.Time
+	// NewTimer will create a new timer firing once supplied duration elapsed.
+	NewTimer(time.Duration) Timer
+	// NewTicker will create a new ticker firing every supplied period.
+	NewTicker(time.Duration) Ticker
+}
+
+// Timer is the interface of timers created by a clock.
+type Timer interface {
+	// C will return the channel receiving the time when the timer fires.
+	C() <-chan time.Time
+	// Stop will prevent the timer from firing, returning false if it already fired or was stopped.
+	Stop() bool
+	// Reset will change the timer to fire once supplied duration elapsed, returning false if it already fired or was
+	// stopped.
+	Reset(time.Duration) bool
+}
+
+// Ticker is the interface of tickers created by a clock.
+type Ticker interface {
+	// C will return the channel receiving the time at each tick.
+	C() <-chan time.Time
+	// Stop will turn off the ticker.
+	Stop()
+}
+
+// System is the clock backed by the time package.
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+func (t systemTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t systemTicker) Stop() {
+	t.ticker.Stop()
+}
@@ -0,0 +1,165 @@
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake is a clock whose time only changes when Set or Advance are invoked. Timers and tickers fire synchronously,
+// in deadline order, while the time is moved forward. It is safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// NewFake will create a new fake clock set at supplied time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now will return the current time of the fake clock.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since will return the time elapsed since supplied instant, according to the fake clock.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After will return a channel receiving the time once supplied duration elapsed on the fake clock.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// NewTimer will create a new timer firing once supplied duration elapsed on the fake clock.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{&fakeWaiter{clock: f, c: make(chan time.Time, 1)}}
+	t.Reset(d)
+	return t
+}
+
+// NewTicker will create a new ticker firing every supplied period of the fake clock.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+	t := &fakeTicker{&fakeWaiter{clock: f, c: make(chan time.Time, 1), period: d}}
+	t.reset(d)
+	return t
+}
+
+// Advance will move the fake clock forward by supplied duration, firing the timers and tickers expiring meanwhile.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set will move the fake clock to supplied time, firing the timers and tickers expiring meanwhile. Moving the clock
+// backward does not fire anything.
+func (f *Fake) Set(t time.Time) {
+	for {
+		f.mu.Lock()
+		if len(f.waiters) == 0 || f.waiters[0].deadline.After(t) {
+			f.now = t
+			f.mu.Unlock()
+			return
+		}
+		w := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		if w.deadline.After(f.now) {
+			f.now = w.deadline
+		}
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+			f.schedule(w)
+		}
+		now := f.now
+		f.mu.Unlock()
+		select {
+		case w.c <- now:
+		default:
+		}
+	}
+}
+
+// Waiters will return the number of timers and tickers waiting to fire, useful to synchronize with goroutines
+// scheduling them.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+func (f *Fake) schedule(w *fakeWaiter) {
+	i := sort.Search(len(f.waiters), func(i int) bool {
+		return f.waiters[i].deadline.After(w.deadline)
+	})
+	f.waiters = append(f.waiters, nil)
+	copy(f.waiters[i+1:], f.waiters[i:])
+	f.waiters[i] = w
+}
+
+func (f *Fake) unschedule(w *fakeWaiter) bool {
+	for i, waiter := range f.waiters {
+		if waiter == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeWaiter is the scheduled entry of timers and tickers; tickers have a positive period.
+type fakeWaiter struct {
+	clock    *Fake
+	c        chan time.Time
+	deadline time.Time
+	period   time.Duration
+}
+
+func (w *fakeWaiter) C() <-chan time.Time {
+	return w.c
+}
+
+func (w *fakeWaiter) stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.unschedule(w)
+}
+
+func (w *fakeWaiter) reset(d time.Duration) bool {
+	w.clock.mu.Lock()
+	active := w.clock.unschedule(w)
+	w.deadline = w.clock.now.Add(d)
+	w.clock.schedule(w)
+	now := w.clock.now
+	w.clock.mu.Unlock()
+	if d <= 0 {
+		w.clock.Set(now)
+	}
+	return active
+}
+
+type fakeTimer struct {
+	*fakeWaiter
+}
+
+func (t *fakeTimer) Stop() bool {
+	return t.stop()
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	return t.reset(d)
+}
+
+type fakeTicker struct {
+	*fakeWaiter
+}
+
+func (t *fakeTicker) Stop() {
+	t.stop()
+}
@@ -0,0 +1,104 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/clock"
+	. "github.com/maurofran/kit/testing"
+)
+
+var start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func fired(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case t := <-c:
+		return t, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestSystem(t *testing.T) {
+	before := time.Now()
+	now := clock.System.Now()
+
+	Assert(t, !now.Before(before), "unexpected now %v", now)
+	_, ok := <-clock.System.After(time.Millisecond)
+	Equals(t, true, ok)
+}
+
+func TestFake_SetAndAdvance(t *testing.T) {
+	c := clock.NewFake(start)
+
+	Equals(t, start, c.Now())
+	c.Advance(time.Hour)
+	Equals(t, start.Add(time.Hour), c.Now())
+	Equals(t, time.Hour, c.Since(start))
+	c.Set(start)
+	Equals(t, start, c.Now())
+}
+
+func TestFake_Timer(t *testing.T) {
+	c := clock.NewFake(start)
+	timer := c.NewTimer(time.Minute)
+	c.Advance(59 * time.Second)
+	_, ok := fired(timer.C())
+
+	Equals(t, false, ok)
+
+	c.Advance(2 * time.Second)
+	at, ok := fired(timer.C())
+
+	Equals(t, true, ok)
+	Equals(t, start.Add(time.Minute), at)
+	Equals(t, start.Add(61*time.Second), c.Now())
+	Equals(t, false, timer.Stop())
+}
+
+func TestFake_TimerStopAndReset(t *testing.T) {
+	c := clock.NewFake(start)
+	timer := c.NewTimer(time.Minute)
+
+	Equals(t, true, timer.Stop())
+	c.Advance(time.Hour)
+	_, ok := fired(timer.C())
+	Equals(t, false, ok)
+
+	Equals(t, false, timer.Reset(time.Second))
+	c.Advance(time.Second)
+	_, ok = fired(timer.C())
+	Equals(t, true, ok)
+}
+
+func TestFake_TimersFireInOrder(t *testing.T) {
+	c := clock.NewFake(start)
+	late := c.After(2 * time.Minute)
+	early := c.After(time.Minute)
+	c.Advance(time.Hour)
+	earlyAt, _ := fired(early)
+	lateAt, _ := fired(late)
+
+	Equals(t, start.Add(time.Minute), earlyAt)
+	Equals(t, start.Add(2*time.Minute), lateAt)
+}
+
+func TestFake_Ticker(t *testing.T) {
+	c := clock.NewFake(start)
+	ticker := c.NewTicker(time.Minute)
+	ticks := make([]time.Time, 0)
+	for i := 0; i < 3; i++ {
+		c.Advance(time.Minute)
+		at, ok := fired(ticker.C())
+		Equals(t, true, ok)
+		ticks = append(ticks, at)
+	}
+
+	Equals(t, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute), start.Add(3 * time.Minute)}, ticks)
+
+	ticker.Stop()
+	c.Advance(time.Hour)
+	_, ok := fired(ticker.C())
+	Equals(t, false, ok)
+	Equals(t, 0, c.Waiters())
+}
@@ -0,0 +1,143 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"io"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+)
+
+// binaryFormat is the version of the binary format, written as first byte of each envelope.
+const binaryFormat byte = 1
+
+// BinaryCodec is the codec serializing envelopes in a compact length-prefixed format. Event payloads and metadata
+// are encoded with gob, so metadata values of types other than the basic ones must be registered with gob.Register.
+type BinaryCodec struct {
+	registry *Registry
+}
+
+// NewBinaryCodec will create a new binary codec resolving event types through supplied registry.
+func NewBinaryCodec(registry *Registry) (*BinaryCodec, error) {
+	if err := assert.NotNil(registry, "registry"); err != nil {
+		return nil, err
+	}
+	return &BinaryCodec{registry}, nil
+}
+
+// Marshal will serialize the supplied envelope.
+func (c *BinaryCodec) Marshal(envelope *domain.Envelope) ([]byte, error) {
+	if err := assert.NotNil(envelope, "envelope"); err != nil {
+		return nil, err
+	}
+	var md bytes.Buffer
+	if err := gob.NewEncoder(&md).Encode(envelope.Metadata().Entries()); err != nil {
+		return nil, err
+	}
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(envelope.Event()); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte(binaryFormat)
+	writeBytes(&buf, []byte(envelope.EventID()))
+	writeBytes(&buf, []byte(envelope.StreamID()))
+	writeUvarint(&buf, uint64(envelope.Sequence()))
+	writeBytes(&buf, []byte(envelope.Event().Type()))
+	writeUvarint(&buf, uint64(envelope.Event().Version()))
+	writeBytes(&buf, md.Bytes())
+	writeBytes(&buf, payload.Bytes())
+	return buf.Bytes(), nil
+}
+
+// Unmarshal will deserialize an envelope from supplied data.
+func (c *BinaryCodec) Unmarshal(data []byte) (*domain.Envelope, error) {
+	r := &binaryReader{r: bytes.NewReader(data)}
+	if format := r.byte(); r.err == nil && format != binaryFormat {
+		return nil, errors.New("unsupported binary envelope format")
+	}
+	eventID := string(r.bytes())
+	streamID := string(r.bytes())
+	sequence := int(r.uvarint())
+	eventType := string(r.bytes())
+	version := int(r.uvarint())
+	mdData := r.bytes()
+	payload := r.bytes()
+	if r.err != nil {
+		return nil, r.err
+	}
+	entries := make(map[string]interface{})
+	if err := gob.NewDecoder(bytes.NewReader(mdData)).Decode(&entries); err != nil {
+		return nil, err
+	}
+	event, err := c.registry.decode(eventType, version, func(target interface{}) error {
+		return gob.NewDecoder(bytes.NewReader(payload)).Decode(target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewEnvelope(eventID, streamID, sequence, event, metadata.From(entries))
+}
+
+func writeUvarint(buf *bytes.Buffer, value uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], value)
+	buf.Write(tmp[:n])
+}
+
+func writeBytes(buf *bytes.Buffer, data []byte) {
+	writeUvarint(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+// binaryReader reads the fields of a binary envelope, remembering the first error occurred.
+type binaryReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+func (r *binaryReader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	b, err := r.r.ReadByte()
+	r.fail(err)
+	return b
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	value, err := binary.ReadUvarint(r.r)
+	r.fail(err)
+	return value
+}
+
+func (r *binaryReader) bytes() []byte {
+	length := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if length > uint64(r.r.Len()) {
+		r.fail(io.ErrUnexpectedEOF)
+		return nil
+	}
+	data := make([]byte, length)
+	_, err := io.ReadFull(r.r, data)
+	r.fail(err)
+	return data
+}
+
+func (r *binaryReader) fail(err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if r.err == nil {
+		r.err = err
+	}
+}
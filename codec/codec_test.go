@@ -0,0 +1,136 @@
+package codec_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+	. "github.com/maurofran/kit/testing"
+)
+
+type orderPlaced struct {
+	OrderID  string
+	Amount   int
+	PlacedAt time.Time
+}
+
+func (e orderPlaced) Type() string {
+	return "OrderPlaced"
+}
+
+func (e orderPlaced) OccurredOn() time.Time {
+	return e.PlacedAt
+}
+
+func (e orderPlaced) Version() int {
+	return 1
+}
+
+type orderShipped struct {
+	OrderID string
+}
+
+func (e *orderShipped) Type() string {
+	return "OrderShipped"
+}
+
+func (e *orderShipped) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e *orderShipped) Version() int {
+	return 1
+}
+
+type otherOrderPlaced struct {
+	orderPlaced
+}
+
+func aRegistry() *codec.Registry {
+	r := codec.NewRegistry()
+	r.Register(orderPlaced{}, &orderShipped{})
+	return r
+}
+
+func anEnvelope(event domain.Event) *domain.Envelope {
+	md := metadata.With(domain.UserKey, "john").And(domain.CausationIDKey, "event-0")
+	e, _ := domain.NewEnvelope("event-1", "order-1", 3, event, md)
+	return e
+}
+
+func codecsFor(r *codec.Registry) map[string]codec.Codec {
+	jsonCodec, _ := codec.NewJSONCodec(r)
+	binaryCodec, _ := codec.NewBinaryCodec(r)
+	return map[string]codec.Codec{"json": jsonCodec, "binary": binaryCodec}
+}
+
+func codecs() map[string]codec.Codec {
+	return codecsFor(aRegistry())
+}
+
+func TestRegister_Conflict(t *testing.T) {
+	err := aRegistry().Register(otherOrderPlaced{})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestRegistered(t *testing.T) {
+	r := aRegistry()
+
+	Equals(t, true, r.Registered("OrderPlaced", 1))
+	Equals(t, false, r.Registered("OrderPlaced", 2))
+}
+
+func TestRoundTrip(t *testing.T) {
+	placedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, c := range codecs() {
+		data, err := c.Marshal(anEnvelope(orderPlaced{"order-1", 42, placedAt}))
+		Ok(t, err)
+		e, err := c.Unmarshal(data)
+
+		Ok(t, err)
+		Equals(t, "event-1", e.EventID())
+		Equals(t, "order-1", e.StreamID())
+		Equals(t, 3, e.Sequence())
+		Equals(t, "john", e.User())
+		Equals(t, "event-0", e.CausationID())
+		Equals(t, "event-1", e.CorrelationID())
+		placed, ok := e.Event().(orderPlaced)
+		Assert(t, ok, "%s: unexpected event type %T", name, e.Event())
+		Equals(t, "order-1", placed.OrderID)
+		Equals(t, 42, placed.Amount)
+		Assert(t, placedAt.Equal(placed.PlacedAt), "%s: unexpected placed at %v", name, placed.PlacedAt)
+	}
+}
+
+func TestRoundTrip_PointerEvent(t *testing.T) {
+	for _, c := range codecs() {
+		data, err := c.Marshal(anEnvelope(&orderShipped{"order-1"}))
+		Ok(t, err)
+		e, err := c.Unmarshal(data)
+
+		Ok(t, err)
+		Equals(t, &orderShipped{"order-1"}, e.Event())
+	}
+}
+
+func TestUnmarshal_UnknownType(t *testing.T) {
+	unregistered := codecsFor(codec.NewRegistry())
+	for name, c := range codecs() {
+		data, _ := c.Marshal(anEnvelope(orderPlaced{}))
+		_, err := unregistered[name].Unmarshal(data)
+
+		Assert(t, codec.IsUnknownTypeError(err), "should return an unknown type error")
+	}
+}
+
+func TestUnmarshal_TruncatedBinary(t *testing.T) {
+	c := codecs()["binary"]
+	data, _ := c.Marshal(anEnvelope(orderPlaced{}))
+	_, err := c.Unmarshal(data[:len(data)/2])
+
+	Assert(t, err != nil, "should return an error")
+}
@@ -0,0 +1,68 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+)
+
+type jsonEnvelope struct {
+	EventID   string                 `json:"eventId"`
+	StreamID  string                 `json:"streamId,omitempty"`
+	Sequence  int                    `json:"sequence"`
+	EventType string                 `json:"type"`
+	Version   int                    `json:"version"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Payload   json.RawMessage        `json:"payload"`
+}
+
+// JSONCodec is the codec serializing envelopes as JSON documents. Metadata values are restored as generic JSON
+// values, so numbers are deserialized as float64.
+type JSONCodec struct {
+	registry *Registry
+}
+
+// NewJSONCodec will create a new JSON codec resolving event types through supplied registry.
+func NewJSONCodec(registry *Registry) (*JSONCodec, error) {
+	if err := assert.NotNil(registry, "registry"); err != nil {
+		return nil, err
+	}
+	return &JSONCodec{registry}, nil
+}
+
+// Marshal will serialize the supplied envelope.
+func (c *JSONCodec) Marshal(envelope *domain.Envelope) ([]byte, error) {
+	if err := assert.NotNil(envelope, "envelope"); err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(envelope.Event())
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonEnvelope{
+		EventID:   envelope.EventID(),
+		StreamID:  envelope.StreamID(),
+		Sequence:  envelope.Sequence(),
+		EventType: envelope.Event().Type(),
+		Version:   envelope.Event().Version(),
+		Metadata:  envelope.Metadata().Entries(),
+		Payload:   payload,
+	})
+}
+
+// Unmarshal will deserialize an envelope from supplied data.
+func (c *JSONCodec) Unmarshal(data []byte) (*domain.Envelope, error) {
+	var je jsonEnvelope
+	if err := json.Unmarshal(data, &je); err != nil {
+		return nil, err
+	}
+	event, err := c.registry.decode(je.EventType, je.Version, func(target interface{}) error {
+		return json.Unmarshal(je.Payload, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewEnvelope(je.EventID, je.StreamID, je.Sequence, event, metadata.From(je.Metadata))
+}
@@ -0,0 +1,121 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+)
+
+// Codec is the interface implemented by objects serializing event envelopes.
+type Codec interface {
+	// Marshal will serialize the supplied envelope.
+	Marshal(*domain.Envelope) ([]byte, error)
+	// Unmarshal will deserialize an envelope from supplied data.
+	Unmarshal([]byte) (*domain.Envelope, error)
+}
+
+type typeKey struct {
+	eventType string
+	version   int
+}
+
+type unknownTypeError struct {
+	eventType string
+	version   int
+}
+
+func (err unknownTypeError) Error() string {
+	return fmt.Sprintf("event type %s version %d is not registered", err.eventType, err.version)
+}
+
+// IsUnknownTypeError verify if the supplied error is raised for an event type that is not registered.
+func IsUnknownTypeError(err error) bool {
+	_, ok := err.(unknownTypeError)
+	return ok
+}
+
+// Registry is the thread-safe registry mapping event types and versions to Go types. Events of older versions are
+// upcasted to the latest registered version of their type when decoded.
+type Registry struct {
+	mu        sync.RWMutex
+	types     map[typeKey]reflect.Type
+	latest    map[string]int
+	upcasters map[typeKey]UpcastFunc
+}
+
+// NewRegistry will create a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		types:     make(map[typeKey]reflect.Type),
+		latest:    make(map[string]int),
+		upcasters: make(map[typeKey]UpcastFunc),
+	}
+}
+
+// Register will map the type and version of supplied events to their Go types. Registering the same type and version
+// with a different Go type is an error.
+func (r *Registry) Register(events ...domain.Event) error {
+	for _, event := range events {
+		if err := assert.NotNil(event, "event"); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range events {
+		key := typeKey{event.Type(), event.Version()}
+		typ := reflect.TypeOf(event)
+		if existing, ok := r.types[key]; ok && existing != typ {
+			return assert.State(false, fmt.Sprintf("event type %s version %d is already registered as %s",
+				key.eventType, key.version, existing))
+		}
+		r.types[key] = typ
+		if latest, ok := r.latest[key.eventType]; !ok || key.version > latest {
+			r.latest[key.eventType] = key.version
+		}
+	}
+	return nil
+}
+
+// Registered will check if the supplied event type and version are registered.
+func (r *Registry) Registered(eventType string, version int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.types[typeKey{eventType, version}]
+	return ok
+}
+
+// decode will create a new event of supplied type and version, filling it through the supplied function and upcasting
+// it to the latest registered version.
+func (r *Registry) decode(eventType string, version int, fill func(target interface{}) error) (domain.Event, error) {
+	event, err := r.instantiate(eventType, version, fill)
+	if err != nil {
+		return nil, err
+	}
+	return r.upcast(event)
+}
+
+func (r *Registry) instantiate(eventType string, version int, fill func(target interface{}) error) (domain.Event,
+	error) {
+	r.mu.RLock()
+	typ, ok := r.types[typeKey{eventType, version}]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, unknownTypeError{eventType, version}
+	}
+	if typ.Kind() == reflect.Ptr {
+		target := reflect.New(typ.Elem())
+		if err := fill(target.Interface()); err != nil {
+			return nil, err
+		}
+		return target.Interface().(domain.Event), nil
+	}
+	target := reflect.New(typ)
+	if err := fill(target.Interface()); err != nil {
+		return nil, err
+	}
+	return target.Elem().Interface().(domain.Event), nil
+}
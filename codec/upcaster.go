@@ -0,0 +1,110 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+)
+
+// UpcastFunc is the function transforming an event into the next version of the same event type.
+type UpcastFunc func(domain.Event) (domain.Event, error)
+
+type missingUpcasterError struct {
+	eventType   string
+	fromVersion int
+}
+
+func (err missingUpcasterError) Error() string {
+	return fmt.Sprintf("no upcaster registered for event type %s from version %d to version %d", err.eventType,
+		err.fromVersion, err.fromVersion+1)
+}
+
+// IsMissingUpcasterError verify if the supplied error is raised for a missing upcaster.
+func IsMissingUpcasterError(err error) bool {
+	_, ok := err.(missingUpcasterError)
+	return ok
+}
+
+// RegisterUpcaster will register the function upcasting events of supplied type from supplied version to the next
+// one. Registering an upcaster twice for the same type and version is an error.
+func (r *Registry) RegisterUpcaster(eventType string, fromVersion int, upcast UpcastFunc) error {
+	if err := assert.NotEmpty(eventType, "eventType"); err != nil {
+		return err
+	}
+	if err := assert.NotNil(upcast, "upcast"); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := typeKey{eventType, fromVersion}
+	if _, ok := r.upcasters[key]; ok {
+		return assert.State(false, fmt.Sprintf("upcaster for event type %s from version %d is already registered",
+			eventType, fromVersion))
+	}
+	r.upcasters[key] = upcast
+	return nil
+}
+
+// Validate will check that, for each event type, an upcaster is registered for every version between the oldest
+// registered one and the latest, and that no upcaster goes beyond the latest registered version.
+func (r *Registry) Validate() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	oldest := make(map[string]int)
+	for key := range r.types {
+		if version, ok := oldest[key.eventType]; !ok || key.version < version {
+			oldest[key.eventType] = key.version
+		}
+	}
+	eventTypes := make([]string, 0, len(oldest))
+	for eventType := range oldest {
+		eventTypes = append(eventTypes, eventType)
+	}
+	sort.Strings(eventTypes)
+	for _, eventType := range eventTypes {
+		for version := oldest[eventType]; version < r.latest[eventType]; version++ {
+			if _, ok := r.upcasters[typeKey{eventType, version}]; !ok {
+				return missingUpcasterError{eventType, version}
+			}
+		}
+	}
+	for key := range r.upcasters {
+		latest, ok := r.latest[key.eventType]
+		if !ok || key.version >= latest {
+			return assert.State(false, fmt.Sprintf("upcaster for event type %s from version %d has no target version",
+				key.eventType, key.version))
+		}
+	}
+	return nil
+}
+
+// upcast will transform the supplied event, one version at a time, to the latest registered version of its type.
+func (r *Registry) upcast(event domain.Event) (domain.Event, error) {
+	r.mu.RLock()
+	latest := r.latest[event.Type()]
+	r.mu.RUnlock()
+	for event.Version() < latest {
+		eventType, version := event.Type(), event.Version()
+		r.mu.RLock()
+		upcast, ok := r.upcasters[typeKey{eventType, version}]
+		r.mu.RUnlock()
+		if !ok {
+			return nil, missingUpcasterError{eventType, version}
+		}
+		upcasted, err := upcast(event)
+		if err != nil {
+			return nil, err
+		}
+		if err := assert.NotNil(upcasted, "upcasted event"); err != nil {
+			return nil, err
+		}
+		if upcasted.Type() != eventType || upcasted.Version() != version+1 {
+			return nil, assert.State(false, fmt.Sprintf("upcaster for event type %s from version %d returned %s "+
+				"version %d", eventType, version, upcasted.Type(), upcasted.Version()))
+		}
+		event = upcasted
+	}
+	return event, nil
+}
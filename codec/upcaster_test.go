@@ -0,0 +1,166 @@
+package codec_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/domain"
+	. "github.com/maurofran/kit/testing"
+)
+
+type customerRenamedV1 struct {
+	Name string
+}
+
+func (e customerRenamedV1) Type() string {
+	return "CustomerRenamed"
+}
+
+func (e customerRenamedV1) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e customerRenamedV1) Version() int {
+	return 1
+}
+
+type customerRenamedV2 struct {
+	FirstName string
+	LastName  string
+}
+
+func (e customerRenamedV2) Type() string {
+	return "CustomerRenamed"
+}
+
+func (e customerRenamedV2) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e customerRenamedV2) Version() int {
+	return 2
+}
+
+type customerRenamedV3 struct {
+	FirstName string
+	LastName  string
+	Title     string
+}
+
+func (e customerRenamedV3) Type() string {
+	return "CustomerRenamed"
+}
+
+func (e customerRenamedV3) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e customerRenamedV3) Version() int {
+	return 3
+}
+
+func v1ToV2(event domain.Event) (domain.Event, error) {
+	names := strings.SplitN(event.(customerRenamedV1).Name, " ", 2)
+	return customerRenamedV2{names[0], names[1]}, nil
+}
+
+func v2ToV3(event domain.Event) (domain.Event, error) {
+	v2 := event.(customerRenamedV2)
+	return customerRenamedV3{v2.FirstName, v2.LastName, ""}, nil
+}
+
+func anUpcastingRegistry() *codec.Registry {
+	r := codec.NewRegistry()
+	r.Register(customerRenamedV1{}, customerRenamedV2{}, customerRenamedV3{})
+	r.RegisterUpcaster("CustomerRenamed", 1, v1ToV2)
+	r.RegisterUpcaster("CustomerRenamed", 2, v2ToV3)
+	return r
+}
+
+func TestUpcast_ChainToLatest(t *testing.T) {
+	for _, c := range codecsFor(anUpcastingRegistry()) {
+		data, _ := c.Marshal(anEnvelope(customerRenamedV1{"John Doe"}))
+		e, err := c.Unmarshal(data)
+
+		Ok(t, err)
+		Equals(t, customerRenamedV3{"John", "Doe", ""}, e.Event())
+	}
+}
+
+func TestUpcast_LatestVersionUntouched(t *testing.T) {
+	for _, c := range codecsFor(anUpcastingRegistry()) {
+		data, _ := c.Marshal(anEnvelope(customerRenamedV3{"John", "Doe", "Mr"}))
+		e, err := c.Unmarshal(data)
+
+		Ok(t, err)
+		Equals(t, customerRenamedV3{"John", "Doe", "Mr"}, e.Event())
+	}
+}
+
+func TestUpcast_MissingUpcaster(t *testing.T) {
+	r := codec.NewRegistry()
+	r.Register(customerRenamedV1{}, customerRenamedV2{}, customerRenamedV3{})
+	r.RegisterUpcaster("CustomerRenamed", 1, v1ToV2)
+	c, _ := codec.NewJSONCodec(r)
+	data, _ := c.Marshal(anEnvelope(customerRenamedV1{"John Doe"}))
+	_, err := c.Unmarshal(data)
+
+	Assert(t, codec.IsMissingUpcasterError(err), "should return a missing upcaster error")
+}
+
+func TestUpcast_Failure(t *testing.T) {
+	r := codec.NewRegistry()
+	r.Register(customerRenamedV1{}, customerRenamedV2{})
+	r.RegisterUpcaster("CustomerRenamed", 1, func(domain.Event) (domain.Event, error) {
+		return nil, errors.New("boom")
+	})
+	c, _ := codec.NewJSONCodec(r)
+	data, _ := c.Marshal(anEnvelope(customerRenamedV1{"John Doe"}))
+	_, err := c.Unmarshal(data)
+
+	Equals(t, errors.New("boom"), err)
+}
+
+func TestUpcast_WrongTargetVersion(t *testing.T) {
+	r := codec.NewRegistry()
+	r.Register(customerRenamedV1{}, customerRenamedV2{}, customerRenamedV3{})
+	c, _ := codec.NewJSONCodec(r)
+	data, _ := c.Marshal(anEnvelope(customerRenamedV2{"John", "Doe"}))
+	r.RegisterUpcaster("CustomerRenamed", 2, func(event domain.Event) (domain.Event, error) {
+		return event, nil
+	})
+	_, err := c.Unmarshal(data)
+
+	Assert(t, err != nil, "should return an error")
+}
+
+func TestValidate(t *testing.T) {
+	Ok(t, anUpcastingRegistry().Validate())
+}
+
+func TestValidate_Gap(t *testing.T) {
+	r := codec.NewRegistry()
+	r.Register(customerRenamedV1{}, customerRenamedV3{})
+	r.RegisterUpcaster("CustomerRenamed", 2, v2ToV3)
+	err := r.Validate()
+
+	Assert(t, codec.IsMissingUpcasterError(err), "should return a missing upcaster error")
+}
+
+func TestValidate_DanglingUpcaster(t *testing.T) {
+	r := codec.NewRegistry()
+	r.Register(customerRenamedV1{})
+	r.RegisterUpcaster("CustomerRenamed", 1, v1ToV2)
+	err := r.Validate()
+
+	Assert(t, err != nil, "should return an error")
+}
+
+func TestRegisterUpcaster_Duplicate(t *testing.T) {
+	err := anUpcastingRegistry().RegisterUpcaster("CustomerRenamed", 1, v1ToV2)
+
+	Assert(t, err != nil, "should return an error")
+}
@@ -0,0 +1,162 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Bus is the thread-safe command bus routing each command to the single handler registered for its type, through a
+// chain of middlewares.
+type Bus struct {
+	mu          sync.RWMutex
+	handlers    map[string]Handler
+	middlewares []Middleware
+}
+
+// NewBus will create a new command bus applying supplied middlewares, the first one being the outermost.
+func NewBus(middlewares ...Middleware) *Bus {
+	return &Bus{handlers: make(map[string]Handler), middlewares: middlewares}
+}
+
+// Register will register the handler for the commands of supplied type. Only one handler can be registered for each
+// command type.
+func (b *Bus) Register(commandType string, handler Handler) error {
+	if err := assert.NotEmpty(commandType, "commandType"); err != nil {
+		return err
+	}
+	if err := assert.NotNil(handler, "handler"); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.handlers[commandType]; ok {
+		return assert.State(false, fmt.Sprintf("a handler is already registered for command type %s", commandType))
+	}
+	for i := len(b.middlewares) - 1; i >= 0; i-- {
+		handler = b.middlewares[i](handler)
+	}
+	b.handlers[commandType] = handler
+	return nil
+}
+
+// Dispatch will synchronously execute the supplied command with its registered handler.
+func (b *Bus) Dispatch(ctx context.Context, command Command) error {
+	if err := assert.NotNil(command, "command"); err != nil {
+		return err
+	}
+	b.mu.RLock()
+	handler, ok := b.handlers[command.CommandType()]
+	b.mu.RUnlock()
+	if !ok {
+		return noHandlerError{command.CommandType()}
+	}
+	return handler.Handle(ctx, command)
+}
+
+// ErrorHandler is the function invoked when a queued command failed.
+type ErrorHandler func(Command, error)
+
+type queuedCommand struct {
+	ctx     context.Context
+	command Command
+}
+
+// detachedContext is the context carrying the values of its parent without its cancellation and deadline, like
+// context.WithoutCancel since Go 1.21.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (ctx detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (ctx detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (ctx detachedContext) Err() error {
+	return nil
+}
+
+func (ctx detachedContext) Value(key interface{}) interface{} {
+	return ctx.parent.Value(key)
+}
+
+// QueuedBus is the command bus executing commands asynchronously through a pool of workers consuming a bounded
+// queue.
+type QueuedBus struct {
+	bus     *Bus
+	onError ErrorHandler
+	queue   chan queuedCommand
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewQueuedBus will create a new queued bus executing commands on supplied bus using supplied number of workers.
+// Dispatch blocks when queueSize commands are waiting to be executed. Failures are reported to onError, that can be
+// nil.
+func NewQueuedBus(bus *Bus, workers, queueSize int, onError ErrorHandler) (*QueuedBus, error) {
+	if err := assert.NotNil(bus, "bus"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(workers, 1, "workers"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(queueSize, 0, "queueSize"); err != nil {
+		return nil, err
+	}
+	b := &QueuedBus{bus: bus, onError: onError, queue: make(chan queuedCommand, queueSize)}
+	b.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go b.work()
+	}
+	return b, nil
+}
+
+// Dispatch will enqueue the supplied command for execution, blocking while the queue is full or until the supplied
+// context is done. The command is executed with the values of the context, such as its metadata, but not with its
+// cancellation and deadline, since it usually ends before the command is executed.
+func (b *QueuedBus) Dispatch(ctx context.Context, command Command) error {
+	if err := assert.NotNil(command, "command"); err != nil {
+		return err
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if err := assert.StateNot(b.closed, "command bus is closed"); err != nil {
+		return err
+	}
+	select {
+	case b.queue <- queuedCommand{detachedContext{ctx}, command}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close will stop accepting new commands, waiting for the queued ones to be executed.
+func (b *QueuedBus) Close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+func (b *QueuedBus) work() {
+	defer b.wg.Done()
+	for queued := range b.queue {
+		if err := b.bus.Dispatch(queued.ctx, queued.command); err != nil && b.onError != nil {
+			b.onError(queued.command, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/command"
+	"github.com/maurofran/kit/metadata"
+	. "github.com/maurofran/kit/testing"
+)
+
+type placeOrder struct {
+	orderID string
+	md      *metadata.Container
+}
+
+func (c placeOrder) CommandType() string {
+	return "PlaceOrder"
+}
+
+func (c placeOrder) Validate() error {
+	return assert.NotEmpty(c.orderID, "orderID")
+}
+
+func (c placeOrder) Metadata() *metadata.Container {
+	return c.md
+}
+
+type cancelOrder struct {
+	valid bool
+}
+
+func (c cancelOrder) CommandType() string {
+	return "CancelOrder"
+}
+
+func (c cancelOrder) IsValid() bool {
+	return c.valid
+}
+
+type recordingHandler struct {
+	mu       sync.Mutex
+	commands []command.Command
+	failures int
+	err      error
+	md       *metadata.Container
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, c command.Command) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.commands = append(h.commands, c)
+	h.md = metadata.FromContext(ctx)
+	if h.failures > 0 {
+		h.failures--
+		return h.err
+	}
+	return nil
+}
+
+func (h *recordingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.commands)
+}
+
+func TestDispatch(t *testing.T) {
+	b := command.NewBus()
+	h := new(recordingHandler)
+	b.Register("PlaceOrder", h)
+	err := b.Dispatch(context.Background(), placeOrder{orderID: "order-1"})
+
+	Ok(t, err)
+	Equals(t, []command.Command{placeOrder{orderID: "order-1"}}, h.commands)
+}
+
+func TestDispatch_NoHandler(t *testing.T) {
+	err := command.NewBus().Dispatch(context.Background(), placeOrder{orderID: "order-1"})
+
+	Assert(t, command.IsNoHandlerError(err), "should return a no handler error")
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	b := command.NewBus()
+	b.Register("PlaceOrder", new(recordingHandler))
+	err := b.Register("PlaceOrder", new(recordingHandler))
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestValidation(t *testing.T) {
+	b := command.NewBus(command.Validation())
+	h := new(recordingHandler)
+	b.Register("PlaceOrder", h)
+	b.Register("CancelOrder", h)
+
+	Assert(t, assert.IsArgumentError(b.Dispatch(context.Background(), placeOrder{})), "should return an argument error")
+	Assert(t, assert.IsArgumentError(b.Dispatch(context.Background(), cancelOrder{})), "should return an argument error")
+	Ok(t, b.Dispatch(context.Background(), cancelOrder{true}))
+	Equals(t, 1, h.count())
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	b := command.NewBus(command.Logging(log.New(&buf, "", 0)))
+	b.Register("PlaceOrder", &recordingHandler{failures: 1, err: errors.New("boom")})
+	b.Dispatch(context.Background(), placeOrder{orderID: "order-1"})
+	b.Dispatch(context.Background(), placeOrder{orderID: "order-1"})
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	Equals(t, 2, len(lines))
+	Assert(t, strings.Contains(lines[0], "command PlaceOrder failed"), "unexpected log line %s", lines[0])
+	Assert(t, strings.Contains(lines[1], "command PlaceOrder handled"), "unexpected log line %s", lines[1])
+}
+
+func TestPropagateMetadata(t *testing.T) {
+	b := command.NewBus(command.PropagateMetadata())
+	h := new(recordingHandler)
+	b.Register("PlaceOrder", h)
+	ctx := metadata.NewContext(context.Background(), metadata.With("tenant", "acme"))
+	err := b.Dispatch(ctx, placeOrder{"order-1", metadata.With("tenant", "other").And("user", "john")})
+
+	Ok(t, err)
+	tenant, _ := h.md.Get("tenant")
+	user, _ := h.md.Get("user")
+	Equals(t, "acme", tenant)
+	Equals(t, "john", user)
+}
+
+func TestRetry(t *testing.T) {
+	b := command.NewBus(command.Retry(3, 0, nil))
+	h := &recordingHandler{failures: 2, err: errors.New("boom")}
+	b.Register("PlaceOrder", h)
+	err := b.Dispatch(context.Background(), placeOrder{orderID: "order-1"})
+
+	Ok(t, err)
+	Equals(t, 3, h.count())
+}
+
+func TestRetry_Exhausted(t *testing.T) {
+	b := command.NewBus(command.Retry(3, 0, nil))
+	h := &recordingHandler{failures: 5, err: errors.New("boom")}
+	b.Register("PlaceOrder", h)
+	err := b.Dispatch(context.Background(), placeOrder{orderID: "order-1"})
+
+	Equals(t, errors.New("boom"), err)
+	Equals(t, 3, h.count())
+}
+
+func TestRetry_NotRetryable(t *testing.T) {
+	b := command.NewBus(command.Retry(3, 0, nil))
+	h := &recordingHandler{failures: 5, err: assert.State(false, "conflict")}
+	b.Register("PlaceOrder", h)
+	b.Dispatch(context.Background(), placeOrder{orderID: "order-1"})
+
+	Equals(t, 1, h.count())
+}
+
+func TestQueuedBus(t *testing.T) {
+	b := command.NewBus()
+	h := &recordingHandler{failures: 10, err: errors.New("boom")}
+	b.Register("PlaceOrder", h)
+	var mu sync.Mutex
+	failures := 0
+	q, err := command.NewQueuedBus(b, 3, 2, func(command.Command, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures++
+	})
+	Ok(t, err)
+	for i := 0; i < 50; i++ {
+		Ok(t, q.Dispatch(context.Background(), placeOrder{orderID: "order-1"}))
+	}
+	q.Close()
+
+	Equals(t, 50, h.count())
+	Equals(t, 10, failures)
+	Assert(t, assert.IsStateError(q.Dispatch(context.Background(), placeOrder{})), "should return a state error")
+}
+
+func TestQueuedBus_CancelledContext(t *testing.T) {
+	b := command.NewBus()
+	var handled error
+	var user interface{}
+	release := make(chan struct{})
+	b.Register("PlaceOrder", command.HandlerFunc(func(ctx context.Context, c command.Command) error {
+		<-release
+		handled = ctx.Err()
+		user, _ = metadata.FromContext(ctx).Get("user")
+		return nil
+	}))
+	q, _ := command.NewQueuedBus(b, 1, 1, nil)
+	ctx, cancel := context.WithCancel(metadata.NewContext(context.Background(), metadata.With("user", "john")))
+	Ok(t, q.Dispatch(ctx, placeOrder{orderID: "order-1"}))
+	cancel()
+	close(release)
+	q.Close()
+
+	Ok(t, handled)
+	Equals(t, "john", user)
+}
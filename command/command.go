@@ -0,0 +1,43 @@
+package command
+
+import (
+	"context"
+	"fmt"
+)
+
+// Command is the interface implemented by commands, requests to change the state of the system.
+type Command interface {
+	// CommandType will return the type used to route the command to its handler.
+	CommandType() string
+}
+
+// Handler is the interface implemented by command handlers.
+type Handler interface {
+	// Handle will execute the supplied command, returning an error if it failed.
+	Handle(ctx context.Context, command Command) error
+}
+
+// HandlerFunc is an adapter allowing to use ordinary functions as command handlers.
+type HandlerFunc func(ctx context.Context, command Command) error
+
+// Handle will invoke the receiver function with supplied context and command.
+func (f HandlerFunc) Handle(ctx context.Context, command Command) error {
+	return f(ctx, command)
+}
+
+// Middleware is the function decorating a command handler with additional behaviour.
+type Middleware func(Handler) Handler
+
+type noHandlerError struct {
+	commandType string
+}
+
+func (err noHandlerError) Error() string {
+	return fmt.Sprintf("no handler registered for command type %s", err.commandType)
+}
+
+// IsNoHandlerError verify if the supplied error is raised for a command without registered handler.
+func IsNoHandlerError(err error) bool {
+	_, ok := err.(noHandlerError)
+	return ok
+}
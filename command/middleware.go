@@ -0,0 +1,89 @@
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/metadata"
+)
+
+// Validator is the interface implemented by commands validating themselves with the assert package.
+type Validator interface {
+	// Validate will return an error if the command is not valid.
+	Validate() error
+}
+
+// Logger is the interface of the loggers used by the logging middleware, implemented by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Validation will return a middleware rejecting invalid commands before they reach their handler. Commands
+// implementing Validator are checked with Validate, commands implementing assert.Validatable with assert.IsValid.
+func Validation() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, command Command) error {
+			switch c := command.(type) {
+			case Validator:
+				if err := c.Validate(); err != nil {
+					return err
+				}
+			case assert.Validatable:
+				if err := assert.IsValid(c, "command"); err != nil {
+					return err
+				}
+			}
+			return next.Handle(ctx, command)
+		})
+	}
+}
+
+// Logging will return a middleware logging the outcome and the duration of each command.
+func Logging(logger Logger) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, command Command) error {
+			start := time.Now()
+			err := next.Handle(ctx, command)
+			if err != nil {
+				logger.Printf("command %s failed after %s: %v", command.CommandType(), time.Since(start), err)
+			} else {
+				logger.Printf("command %s handled in %s", command.CommandType(), time.Since(start))
+			}
+			return err
+		})
+	}
+}
+
+// PropagateMetadata will return a middleware adding the metadata of commands implementing metadata.Carrier to the
+// metadata of the context, so that handlers can read them with metadata.FromContext. Context metadata take precedence.
+func PropagateMetadata() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, command Command) error {
+			return next.Handle(metadata.Propagate(ctx, command), command)
+		})
+	}
+}
+
+// Retry will return a middleware executing a failed command up to attempts times, waiting backoff between attempts.
+// Argument and state errors raised by the assert package are never retried, nor are the errors for which retryable,
+// if not nil, returns false.
+func Retry(attempts int, backoff time.Duration, retryable func(error) bool) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, command Command) error {
+			var err error
+			for attempt := 1; ; attempt++ {
+				err = next.Handle(ctx, command)
+				if err == nil || attempt >= attempts || assert.IsArgumentError(err) || assert.IsStateError(err) ||
+					(retryable != nil && !retryable(err)) {
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return err
+				case <-time.After(backoff):
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package deadletter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+)
+
+// Letter is an event parked after its handler failed processing it.
+type Letter struct {
+	// ID is the unique identifier of the letter, made of the handler name and the event id.
+	ID string
+	// Handler is the name of the handler that failed processing the event.
+	Handler string
+	// Envelope is the envelope of the event, carrying its metadata.
+	Envelope *domain.Envelope
+	// Error is the message of the last error returned by the handler.
+	Error string
+	// Attempts is the number of times the handler failed processing the event.
+	Attempts int
+	// ParkedAt is the instant the event was parked.
+	ParkedAt time.Time
+}
+
+// Metadata will return the metadata of the parked event.
+func (l Letter) Metadata() *metadata.Container {
+	return l.Envelope.Metadata()
+}
+
+// Store is the interface implemented by the stores of parked events.
+type Store interface {
+	// Park will save the supplied letter, replacing the letter with the same id if any.
+	Park(letter Letter) error
+	// Get will retrieve the letter with supplied id.
+	Get(id string) (Letter, error)
+	// List will retrieve all the letters, in the order they were parked.
+	List() ([]Letter, error)
+	// Remove will delete the letter with supplied id.
+	Remove(id string) error
+}
+
+type notFoundError struct {
+	id string
+}
+
+func (err notFoundError) Error() string {
+	return fmt.Sprintf("letter %s not found", err.id)
+}
+
+// IsNotFoundError verify if the supplied error is caused by a missing letter.
+func IsNotFoundError(err error) bool {
+	_, ok := err.(notFoundError)
+	return ok
+}
+
+func letterID(handler, eventID string) string {
+	return handler + ":" + eventID
+}
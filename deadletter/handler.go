@@ -0,0 +1,161 @@
+package deadletter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/domain"
+)
+
+// HandlerFunc is the function processing an enveloped event.
+type HandlerFunc func(context.Context, *domain.Envelope) error
+
+// DomainHandler will adapt the supplied domain event handler to a function processing enveloped events.
+func DomainHandler(handler domain.Handler) HandlerFunc {
+	return func(_ context.Context, envelope *domain.Envelope) error {
+		return handler.Handle(envelope.Event())
+	}
+}
+
+// Handler is the named event handler retrying failed events according to its policy, and parking them in a
+// dead-letter store when the attempts are exhausted or the error can not be retried.
+type Handler struct {
+	name    string
+	handler HandlerFunc
+	policy  *Policy
+	store   Store
+	clock   clock.Clock
+}
+
+// NewHandler will create a new handler with supplied unique name, wrapping the handler function with supplied retry
+// policy and parking the failed events in supplied store. Backoffs are waited on the system clock.
+func NewHandler(name string, handler HandlerFunc, policy *Policy, store Store) (*Handler, error) {
+	if err := assert.NotEmpty(name, "name"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(handler, "handler"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(policy, "policy"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(store, "store"); err != nil {
+		return nil, err
+	}
+	return &Handler{name: name, handler: handler, policy: policy, store: store, clock: clock.System}, nil
+}
+
+// WithClock will set the clock used to wait backoffs and timestamp letters, returning the receiver handler. A nil
+// clock is ignored.
+func (h *Handler) WithClock(clk clock.Clock) *Handler {
+	if clk != nil {
+		h.clock = clk
+	}
+	return h
+}
+
+// Name will return the name of the handler.
+func (h *Handler) Name() string {
+	return h.name
+}
+
+// Handle will process the supplied enveloped event, retrying it according to the policy. No error is returned if the
+// event is parked after failing, while the handler error is returned if the context is done before the event is
+// processed or parked.
+func (h *Handler) Handle(ctx context.Context, envelope *domain.Envelope) error {
+	if err := assert.NotNil(envelope, "envelope"); err != nil {
+		return err
+	}
+	_, err := h.handle(ctx, envelope, 0)
+	return err
+}
+
+// Parked will retrieve the letters parked by the handler, in the order they were parked.
+func (h *Handler) Parked() ([]Letter, error) {
+	letters, err := h.store.List()
+	if err != nil {
+		return nil, err
+	}
+	parked := make([]Letter, 0, len(letters))
+	for _, letter := range letters {
+		if letter.Handler == h.name {
+			parked = append(parked, letter)
+		}
+	}
+	return parked, nil
+}
+
+// Replay will process again the event of the letter with supplied id, removing the letter if the event is processed.
+// If the event fails again, the letter is parked again with the attempts of the replay added.
+func (h *Handler) Replay(ctx context.Context, id string) error {
+	letter, err := h.letter(id)
+	if err != nil {
+		return err
+	}
+	parked, err := h.handle(ctx, letter.Envelope, letter.Attempts)
+	if err != nil || parked {
+		return err
+	}
+	return h.store.Remove(id)
+}
+
+// ReplayAll will replay all the letters parked by the handler, stopping at the first error.
+func (h *Handler) ReplayAll(ctx context.Context) error {
+	letters, err := h.Parked()
+	if err != nil {
+		return err
+	}
+	for _, letter := range letters {
+		if err := h.Replay(ctx, letter.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Discard will remove the letter with supplied id without processing its event.
+func (h *Handler) Discard(id string) error {
+	if _, err := h.letter(id); err != nil {
+		return err
+	}
+	return h.store.Remove(id)
+}
+
+func (h *Handler) letter(id string) (Letter, error) {
+	letter, err := h.store.Get(id)
+	if err != nil {
+		return Letter{}, err
+	}
+	if err := assert.State(letter.Handler == h.name, fmt.Sprintf("letter %s was parked by handler %s", id,
+		letter.Handler)); err != nil {
+		return Letter{}, err
+	}
+	return letter, nil
+}
+
+// handle will process the supplied enveloped event, returning true if it was parked after failing.
+func (h *Handler) handle(ctx context.Context, envelope *domain.Envelope, previous int) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		err := h.handler(ctx, envelope)
+		if err == nil {
+			return false, nil
+		}
+		if attempt >= h.policy.MaxAttempts() || !h.policy.Retryable(err) {
+			return true, h.store.Park(Letter{
+				ID:       letterID(h.name, envelope.EventID()),
+				Handler:  h.name,
+				Envelope: envelope,
+				Error:    err.Error(),
+				Attempts: previous + attempt,
+				ParkedAt: h.clock.Now(),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return false, err
+		case <-h.clock.After(h.policy.Backoff(attempt)):
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package deadletter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/deadletter"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+	. "github.com/maurofran/kit/testing"
+)
+
+type testEvent struct {
+	name string
+}
+
+func (e testEvent) Type() string {
+	return e.name
+}
+
+func (e testEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e testEvent) Version() int {
+	return 1
+}
+
+var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type flakyHandler struct {
+	failures int
+	calls    int
+	err      error
+}
+
+func (h *flakyHandler) handle(_ context.Context, _ *domain.Envelope) error {
+	h.calls++
+	if h.calls <= h.failures {
+		return h.err
+	}
+	return nil
+}
+
+func anEnvelope() *domain.Envelope {
+	e, _ := domain.NewEnvelope("event-1", "stream-1", 1, testEvent{"Created"}, metadata.With(domain.UserKey, "john"))
+	return e
+}
+
+func aHandler(fn deadletter.HandlerFunc, store deadletter.Store) *deadletter.Handler {
+	p, _ := deadletter.NewPolicy(3, 0, 0, 0)
+	h, _ := deadletter.NewHandler("handler", fn, p, store)
+	return h.WithClock(clock.NewFake(now))
+}
+
+func TestHandle_Retried(t *testing.T) {
+	fn := &flakyHandler{failures: 2, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+
+	Ok(t, aHandler(fn.handle, s).Handle(context.Background(), anEnvelope()))
+	Equals(t, 3, fn.calls)
+	letters, _ := s.List()
+	Equals(t, 0, len(letters))
+}
+
+func TestHandle_Parked(t *testing.T) {
+	fn := &flakyHandler{failures: 5, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+	h := aHandler(fn.handle, s)
+
+	Ok(t, h.Handle(context.Background(), anEnvelope()))
+	Equals(t, 3, fn.calls)
+	letters, err := h.Parked()
+	Ok(t, err)
+	Equals(t, 1, len(letters))
+	Equals(t, "handler:event-1", letters[0].ID)
+	Equals(t, "boom", letters[0].Error)
+	Equals(t, 3, letters[0].Attempts)
+	Equals(t, now, letters[0].ParkedAt)
+	user, _ := letters[0].Metadata().Get(domain.UserKey)
+	Equals(t, "john", user)
+}
+
+func TestWithClock_Nil(t *testing.T) {
+	fn := &flakyHandler{failures: 5, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+	h := aHandler(fn.handle, s).WithClock(nil)
+
+	Ok(t, h.Handle(context.Background(), anEnvelope()))
+	letters, _ := h.Parked()
+	Equals(t, now, letters[0].ParkedAt)
+}
+
+func TestHandle_NotRetryable(t *testing.T) {
+	fn := &flakyHandler{failures: 5, err: assert.State(false, "invalid")}
+	s := deadletter.NewMemoryStore()
+
+	Ok(t, aHandler(fn.handle, s).Handle(context.Background(), anEnvelope()))
+	Equals(t, 1, fn.calls)
+	letter, err := s.Get("handler:event-1")
+	Ok(t, err)
+	Equals(t, 1, letter.Attempts)
+}
+
+func TestHandle_Cancelled(t *testing.T) {
+	fn := &flakyHandler{failures: 5, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+	p, _ := deadletter.NewPolicy(3, time.Minute, time.Minute, 0)
+	h, _ := deadletter.NewHandler("handler", fn.handle, p, s)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	Equals(t, fn.err, h.Handle(ctx, anEnvelope()))
+	letters, _ := s.List()
+	Equals(t, 0, len(letters))
+}
+
+func TestReplay(t *testing.T) {
+	fn := &flakyHandler{failures: 3, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+	h := aHandler(fn.handle, s)
+	h.Handle(context.Background(), anEnvelope())
+
+	Ok(t, h.Replay(context.Background(), "handler:event-1"))
+	Equals(t, 4, fn.calls)
+	_, err := s.Get("handler:event-1")
+	Assert(t, deadletter.IsNotFoundError(err), "should remove the letter")
+}
+
+func TestReplay_FailingAgain(t *testing.T) {
+	fn := &flakyHandler{failures: 10, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+	h := aHandler(fn.handle, s)
+	h.Handle(context.Background(), anEnvelope())
+
+	Ok(t, h.ReplayAll(context.Background()))
+	letter, err := s.Get("handler:event-1")
+	Ok(t, err)
+	Equals(t, 6, letter.Attempts)
+}
+
+func TestReplay_OtherHandler(t *testing.T) {
+	fn := &flakyHandler{failures: 10, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+	aHandler(fn.handle, s).Handle(context.Background(), anEnvelope())
+	p, _ := deadletter.NewPolicy(1, 0, 0, 0)
+	other, _ := deadletter.NewHandler("other", fn.handle, p, s)
+
+	err := other.Replay(context.Background(), "handler:event-1")
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestDiscard(t *testing.T) {
+	fn := &flakyHandler{failures: 10, err: errors.New("boom")}
+	s := deadletter.NewMemoryStore()
+	h := aHandler(fn.handle, s)
+	h.Handle(context.Background(), anEnvelope())
+
+	Ok(t, h.Discard("handler:event-1"))
+	Equals(t, 3, fn.calls)
+	err := h.Discard("handler:event-1")
+	Assert(t, deadletter.IsNotFoundError(err), "should return a not found error")
+}
+
+func TestDomainHandler(t *testing.T) {
+	var handled domain.Event
+	fn := deadletter.DomainHandler(domain.HandlerFunc(func(event domain.Event) error {
+		handled = event
+		return nil
+	}))
+
+	Ok(t, fn(context.Background(), anEnvelope()))
+	Equals(t, testEvent{"Created"}, handled)
+}
@@ -0,0 +1,72 @@
+package deadletter
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// MemoryStore is a thread-safe store keeping parked events in memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	letters map[string]Letter
+}
+
+// NewMemoryStore will create a new empty in-memory dead-letter store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{letters: make(map[string]Letter)}
+}
+
+// Park will save the supplied letter, replacing the letter with the same id if any.
+func (s *MemoryStore) Park(letter Letter) error {
+	if err := assert.NotEmpty(letter.ID, "letter.ID"); err != nil {
+		return err
+	}
+	if err := assert.NotNil(letter.Envelope, "letter.Envelope"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters[letter.ID] = letter
+	return nil
+}
+
+// Get will retrieve the letter with supplied id.
+func (s *MemoryStore) Get(id string) (Letter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	letter, ok := s.letters[id]
+	if !ok {
+		return Letter{}, notFoundError{id}
+	}
+	return letter, nil
+}
+
+// List will retrieve all the letters, in the order they were parked.
+func (s *MemoryStore) List() ([]Letter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	letters := make([]Letter, 0, len(s.letters))
+	for _, letter := range s.letters {
+		letters = append(letters, letter)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if letters[i].ParkedAt.Equal(letters[j].ParkedAt) {
+			return letters[i].ID < letters[j].ID
+		}
+		return letters[i].ParkedAt.Before(letters[j].ParkedAt)
+	})
+	return letters, nil
+}
+
+// Remove will delete the letter with supplied id.
+func (s *MemoryStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.letters[id]; !ok {
+		return notFoundError{id}
+	}
+	delete(s.letters, id)
+	return nil
+}
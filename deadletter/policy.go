@@ -0,0 +1,71 @@
+package deadletter
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Policy is the retry policy of failing event handlers, waiting an exponentially growing backoff between attempts.
+type Policy struct {
+	maxAttempts int
+	initial     time.Duration
+	max         time.Duration
+	jitter      float64
+	retryable   func(error) bool
+}
+
+// NewPolicy will create a new policy executing a failing handler up to max attempts times. The backoff starts from
+// initial, doubling after each attempt up to max, and is randomly reduced by up to the jitter fraction of it, so that
+// handlers failing together do not retry together.
+func NewPolicy(maxAttempts int, initial, max time.Duration, jitter float64) (*Policy, error) {
+	if err := assert.IntMin(maxAttempts, 1, "maxAttempts"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(initial >= 0, "initial must not be negative"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(max >= initial, "max must be greater or equal than initial"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(jitter >= 0 && jitter <= 1, "jitter must be between 0 and 1"); err != nil {
+		return nil, err
+	}
+	return &Policy{maxAttempts: maxAttempts, initial: initial, max: max, jitter: jitter}, nil
+}
+
+// WithRetryable will set the function checking if an error can be retried, returning the receiver policy.
+func (p *Policy) WithRetryable(retryable func(error) bool) *Policy {
+	p.retryable = retryable
+	return p
+}
+
+// MaxAttempts will return the maximum number of times a failing handler is executed.
+func (p *Policy) MaxAttempts() int {
+	return p.maxAttempts
+}
+
+// Retryable will check if the supplied error can be retried. Argument and state errors raised by the assert package
+// are never retried, nor are the errors rejected by the retryable function of the policy.
+func (p *Policy) Retryable(err error) bool {
+	if assert.IsArgumentError(err) || assert.IsStateError(err) {
+		return false
+	}
+	return p.retryable == nil || p.retryable(err)
+}
+
+// Backoff will return the time to wait after the supplied failed attempt, starting from 1.
+func (p *Policy) Backoff(attempt int) time.Duration {
+	backoff := p.initial
+	for i := 1; i < attempt && backoff < p.max; i++ {
+		backoff *= 2
+	}
+	if backoff > p.max {
+		backoff = p.max
+	}
+	if p.jitter > 0 {
+		backoff -= time.Duration(p.jitter * rand.Float64() * float64(backoff))
+	}
+	return backoff
+}
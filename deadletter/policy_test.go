@@ -0,0 +1,43 @@
+package deadletter_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/deadletter"
+	. "github.com/maurofran/kit/testing"
+)
+
+func TestNewPolicy_InvalidJitter(t *testing.T) {
+	_, err := deadletter.NewPolicy(3, time.Second, time.Minute, 1.5)
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	p, _ := deadletter.NewPolicy(10, time.Second, 5*time.Second, 0)
+
+	Equals(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		[]time.Duration{p.Backoff(1), p.Backoff(2), p.Backoff(3), p.Backoff(4), p.Backoff(5)})
+}
+
+func TestPolicy_BackoffJitter(t *testing.T) {
+	p, _ := deadletter.NewPolicy(10, time.Second, time.Minute, 0.5)
+	for i := 0; i < 100; i++ {
+		backoff := p.Backoff(3)
+
+		Assert(t, backoff > 2*time.Second && backoff <= 4*time.Second, "unexpected backoff %v", backoff)
+	}
+}
+
+func TestPolicy_Retryable(t *testing.T) {
+	permanent := errors.New("permanent")
+	p, _ := deadletter.NewPolicy(3, 0, 0, 0)
+	p.WithRetryable(func(err error) bool { return err != permanent })
+
+	Assert(t, p.Retryable(errors.New("transient")), "should retry errors")
+	Assert(t, !p.Retryable(permanent), "should not retry rejected errors")
+	Assert(t, !p.Retryable(assert.State(false, "invalid")), "should not retry state errors")
+}
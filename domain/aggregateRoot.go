@@ -1,38 +1,189 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Invariant is the function checking a rule that the aggregate state must always satisfy, returning an error
+// (typically built with the assert package) if it's violated.
+type Invariant func() error
+
+type invariantError struct {
+	errors []error
+}
+
+func (err invariantError) Error() string {
+	messages := make([]string, len(err.errors))
+	for i, e := range err.errors {
+		messages[i] = e.Error()
+	}
+	return fmt.Sprintf("%d invariants violated: %s", len(err.errors), strings.Join(messages, "; "))
+}
+
+// Unwrap will return the errors of the violated invariants.
+func (err invariantError) Unwrap() []error {
+	return err.errors
+}
+
+// IsInvariantError verify if the supplied error is raised by violated aggregate invariants.
+func IsInvariantError(err error) bool {
+	_, ok := err.(invariantError)
+	return ok
+}
+
+// Stateful is the interface implemented by aggregates able to take a snapshot of their state and to restore it, used
+// to undo the changes rejected by the invariants.
+type Stateful interface {
+	// Snapshot will return a copy of the aggregate state, not sharing any mutable value with it.
+	Snapshot() interface{}
+	// RestoreSnapshot will replace the aggregate state with the supplied snapshot.
+	RestoreSnapshot(state interface{}) error
+}
+
 // AggregateRoot is the parent struct used to manage domain events.
 type AggregateRoot struct {
-	events []Event
+	events          []Event
+	version         int
+	originalVersion int
+	invariants      []Invariant
+	state           Stateful
 }
 
-// AndEventsFrom will append all the events from supplied aggregate root to the receiver, returning it.
+// AndEventsFrom will append all the events from supplied aggregate root to the receiver, returning it. The version of
+// the receiver is increased by the number of appended events. The receiver invariants are not checked, as the events
+// have already been accepted by the invariants of the other aggregate root.
 func (ar *AggregateRoot) AndEventsFrom(other *AggregateRoot) *AggregateRoot {
 	events := append(ar.events, other.events...)
 	ar.events = events
+	ar.version += len(other.events)
 	return ar
 }
 
-// AndEvent will register the supplied event to the receiver aggregate root, returning it
-func (ar *AggregateRoot) AndEvent(event Event) *AggregateRoot {
-	ar.RegisterEvent(event)
-	return ar
+// AndEvent will register the supplied event to the receiver aggregate root, returning it. The event is rejected if
+// the aggregate invariants are violated.
+func (ar *AggregateRoot) AndEvent(event Event) (*AggregateRoot, error) {
+	if _, err := ar.RegisterEvent(event); err != nil {
+		return ar, err
+	}
+	return ar, nil
 }
 
-// RegisterEvent will queue a new event to this aggregate root, returning the created event.
-func (ar *AggregateRoot) RegisterEvent(event Event) Event {
+// RegisterEvent will queue a new event to this aggregate root, returning the created event. The aggregate invariants
+// are checked first against the current state: if any is violated, the event is rejected, the violations are returned
+// as a single error and the aggregate root is left unchanged. The changes made to the state before calling it are not
+// undone: use Change to have them applied only if the invariants are satisfied.
+func (ar *AggregateRoot) RegisterEvent(event Event) (Event, error) {
+	if err := ar.CheckInvariants(); err != nil {
+		return nil, err
+	}
 	events := append(ar.events, event)
 	ar.events = events
-	return event
+	ar.version++
+	return event, nil
+}
+
+// Change will apply the supplied change to the aggregate state and register the event, checking the invariants
+// against the resulting state. If any is violated, the event is rejected and the state is restored from the snapshot
+// taken before the change, so that neither the state nor the version are changed. Aggregates with invariants must
+// supply their state with TrackState, otherwise a state error is returned without applying the change.
+func (ar *AggregateRoot) Change(event Event, change func()) (Event, error) {
+	if err := assert.NotNil(event, "event"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(change, "change"); err != nil {
+		return nil, err
+	}
+	if len(ar.invariants) == 0 {
+		change()
+		return ar.RegisterEvent(event)
+	}
+	if err := assert.State(ar.state != nil, "the state of an aggregate with invariants must be tracked"); err != nil {
+		return nil, err
+	}
+	snapshot := ar.state.Snapshot()
+	change()
+	if err := ar.CheckInvariants(); err != nil {
+		if restoreErr := ar.state.RestoreSnapshot(snapshot); restoreErr != nil {
+			return nil, restoreErr
+		}
+		return nil, err
+	}
+	return ar.RegisterEvent(event)
+}
+
+// TrackState will set the aggregate whose state is restored when Change rejects an event, usually the aggregate
+// embedding the receiver.
+func (ar *AggregateRoot) TrackState(state Stateful) {
+	ar.state = state
+}
+
+// AddInvariant will add the supplied invariant to the ones checked before registering an event.
+func (ar *AggregateRoot) AddInvariant(invariant Invariant) {
+	ar.invariants = append(ar.invariants, invariant)
+}
+
+// CheckInvariants will check all the invariants of the aggregate root, returning the violations as a single error.
+func (ar *AggregateRoot) CheckInvariants() error {
+	var errs []error
+	for _, invariant := range ar.invariants {
+		if err := invariant(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return invariantError{errs}
+	}
+	return nil
 }
 
 // DomainEvents will return a copy of internal events of receiver aggregate root.
 func (ar *AggregateRoot) DomainEvents() []Event {
-	var events []Event
+	events := make([]Event, len(ar.events))
 	copy(events, ar.events)
 	return events
 }
 
+// DrainDomainEvents will return the domain events of this aggregate root, clearing them.
+func (ar *AggregateRoot) DrainDomainEvents() []Event {
+	events := ar.events
+	ar.events = nil
+	return events
+}
+
 // ClearDomainEvents will clear the domain events of this aggregate root.
 func (ar *AggregateRoot) ClearDomainEvents() {
 	ar.events = nil
 }
+
+// Version will return the current version of the aggregate root, including the uncommitted events.
+func (ar *AggregateRoot) Version() int {
+	return ar.version
+}
+
+// OriginalVersion will return the version of the aggregate root as it was loaded, excluding the uncommitted events.
+func (ar *AggregateRoot) OriginalVersion() int {
+	return ar.originalVersion
+}
+
+// MarkCommitted will clear the domain events of this aggregate root, aligning the original version to the current
+// one.
+func (ar *AggregateRoot) MarkCommitted() {
+	ar.ClearDomainEvents()
+	ar.originalVersion = ar.version
+}
+
+// MarkCommittedEvents will clear the first n domain events of this aggregate root, increasing the original version by
+// n. The events registered after the committed ones are kept.
+func (ar *AggregateRoot) MarkCommittedEvents(n int) {
+	if n > len(ar.events) {
+		n = len(ar.events)
+	}
+	if n <= 0 {
+		return
+	}
+	ar.events = append([]Event(nil), ar.events[n:]...)
+	ar.originalVersion += n
+}
@@ -0,0 +1,157 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	. "github.com/maurofran/kit/testing"
+)
+
+type account struct {
+	domain.AggregateRoot
+	balance int
+	limit   int
+}
+
+func (a *account) Snapshot() interface{} {
+	return a.balance
+}
+
+func (a *account) RestoreSnapshot(state interface{}) error {
+	a.balance = state.(int)
+	return nil
+}
+
+func newAccount(limit int) *account {
+	a := &account{limit: limit}
+	a.TrackState(a)
+	a.AddInvariant(func() error {
+		return assert.IntMin(a.balance, 0, "balance")
+	})
+	a.AddInvariant(func() error {
+		return assert.IntMax(a.balance, a.limit, "balance")
+	})
+	return a
+}
+
+func TestDomainEvents_ReturnsCopy(t *testing.T) {
+	ar := new(domain.AggregateRoot)
+	ar.RegisterEvent(incremented{1})
+	events := ar.DomainEvents()
+	events[0] = incremented{2}
+
+	Equals(t, []domain.Event{incremented{1}}, ar.DomainEvents())
+}
+
+func TestAndEventsFrom_IncreasesVersion(t *testing.T) {
+	ar := new(domain.AggregateRoot)
+	ar.RegisterEvent(incremented{1})
+	ar.MarkCommitted()
+	other := new(domain.AggregateRoot)
+	other.RegisterEvent(incremented{2})
+	other.RegisterEvent(incremented{3})
+	ar.AndEventsFrom(other)
+
+	Equals(t, 3, ar.Version())
+	Equals(t, ar.Version()-ar.OriginalVersion(), len(ar.DomainEvents()))
+}
+
+func TestRegisterEvent_InvariantsSatisfied(t *testing.T) {
+	a := newAccount(100)
+	a.balance = 50
+	event, err := a.RegisterEvent(incremented{50})
+
+	Ok(t, err)
+	Equals(t, incremented{50}, event)
+	Equals(t, 1, a.Version())
+	Equals(t, 1, len(a.DomainEvents()))
+}
+
+func TestRegisterEvent_InvariantViolated(t *testing.T) {
+	a := newAccount(100)
+	a.balance = -10
+	event, err := a.RegisterEvent(incremented{-10})
+
+	Assert(t, domain.IsInvariantError(err), "should return an invariant error")
+	Equals(t, nil, event)
+	Equals(t, 0, a.Version())
+	Equals(t, 0, len(a.DomainEvents()))
+}
+
+func TestAndEvent_InvariantViolated(t *testing.T) {
+	a := newAccount(100)
+	a.balance = 200
+	ar, err := a.AndEvent(incremented{200})
+
+	Assert(t, domain.IsInvariantError(err), "should return an invariant error")
+	Assert(t, ar == &a.AggregateRoot, "should return the receiver")
+	Equals(t, 0, len(a.DomainEvents()))
+}
+
+func TestCheckInvariants_AggregatesViolations(t *testing.T) {
+	a := newAccount(-10)
+	a.balance = -5
+	err := a.CheckInvariants()
+
+	Assert(t, domain.IsInvariantError(err), "should return an invariant error")
+	Equals(t, "2 invariants violated: balance must greater or equal than 0; balance must lower or equal than -10",
+		err.Error())
+}
+
+func TestChange_InvariantsSatisfied(t *testing.T) {
+	a := newAccount(100)
+	event, err := a.Change(incremented{50}, func() { a.balance += 50 })
+
+	Ok(t, err)
+	Equals(t, incremented{50}, event)
+	Equals(t, 50, a.balance)
+	Equals(t, 1, a.Version())
+}
+
+func TestChange_InvariantViolated(t *testing.T) {
+	a := newAccount(100)
+	a.Change(incremented{50}, func() { a.balance += 50 })
+	_, err := a.Change(incremented{60}, func() { a.balance += 60 })
+
+	Assert(t, domain.IsInvariantError(err), "should return an invariant error")
+	Equals(t, 50, a.balance)
+	Equals(t, 1, a.Version())
+	Equals(t, []domain.Event{incremented{50}}, a.DomainEvents())
+}
+
+func TestChange_UntrackedState(t *testing.T) {
+	a := &account{limit: 100}
+	a.AddInvariant(func() error {
+		return assert.IntMax(a.balance, a.limit, "balance")
+	})
+	_, err := a.Change(incremented{50}, func() { a.balance += 50 })
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+	Equals(t, 0, a.balance)
+	Equals(t, 0, a.Version())
+}
+
+func (c *counter) Snapshot() interface{} {
+	return c.value
+}
+
+func (c *counter) RestoreSnapshot(state interface{}) error {
+	c.value = state.(int)
+	return nil
+}
+
+func TestApply_InvariantViolated(t *testing.T) {
+	c := newCounter()
+	c.TrackState(c)
+	c.AddInvariant(func() error {
+		return assert.IntMax(c.value, 3, "value")
+	})
+	Ok(t, c.Apply(incremented{3}))
+	err := c.Apply(incremented{1})
+
+	Assert(t, domain.IsInvariantError(err), "should return an invariant error")
+	Equals(t, 3, c.value)
+	Equals(t, 1, c.Version())
+	Equals(t, []domain.Event{incremented{3}}, c.DomainEvents())
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/id"
+)
+
+// Clock is the interface implemented by objects telling the current time. It is satisfied by clock.Clock.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter allowing to use ordinary functions as clocks.
+type ClockFunc func() time.Time
+
+// Now will invoke the receiver function.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// IDGenerator is the interface implemented by objects generating unique identifiers. It is satisfied by id.Generator.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc is an adapter allowing to use ordinary functions as identifier generators.
+type IDGeneratorFunc func() string
+
+// NewID will invoke the receiver function.
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// BaseEvent is the struct that can be embedded by domain events to implement the Event interface. Its fields are
+// exported so that events can be serialized.
+type BaseEvent struct {
+	EventID      string
+	EventType    string
+	EventVersion int
+	OccurredAt   time.Time
+}
+
+// ID will return the unique identifier of the event.
+func (e BaseEvent) ID() string {
+	return e.EventID
+}
+
+// Type will return the type of the event.
+func (e BaseEvent) Type() string {
+	return e.EventType
+}
+
+// OccurredOn will return the instant the event occurred.
+func (e BaseEvent) OccurredOn() time.Time {
+	return e.OccurredAt
+}
+
+// Version will return the version of the event type.
+func (e BaseEvent) Version() int {
+	return e.EventVersion
+}
+
+// EventFactory is the object creating base events with a pluggable clock and identifier generator.
+type EventFactory struct {
+	clock Clock
+	ids   IDGenerator
+}
+
+// NewEventFactory will create a new event factory using supplied clock and identifier generator.
+func NewEventFactory(clock Clock, ids IDGenerator) (*EventFactory, error) {
+	if err := assert.NotNil(clock, "clock"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(ids, "ids"); err != nil {
+		return nil, err
+	}
+	return &EventFactory{clock, ids}, nil
+}
+
+// New will create a new base event of supplied type and version, identified and timestamped by the factory.
+func (f *EventFactory) New(eventType string, version int) BaseEvent {
+	return BaseEvent{
+		EventID:      f.ids.NewID(),
+		EventType:    eventType,
+		EventVersion: version,
+		OccurredAt:   f.clock.Now(),
+	}
+}
+
+var uuids, _ = id.NewUUIDv4Generator(nil)
+
+var defaultEventFactory = &EventFactory{clock.System, uuids}
+
+// NewBaseEvent will create a new base event of supplied type and version, occurred now and identified by a random
+// UUID.
+func NewBaseEvent(eventType string, version int) BaseEvent {
+	return defaultEventFactory.New(eventType, version)
+}
@@ -0,0 +1,58 @@
+package domain_test
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	. "github.com/maurofran/kit/testing"
+)
+
+type orderPlaced struct {
+	domain.BaseEvent
+	OrderID string
+}
+
+var _ domain.Event = orderPlaced{}
+
+func TestEventFactory(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	next := 0
+	f, err := domain.NewEventFactory(domain.ClockFunc(func() time.Time {
+		return now
+	}), domain.IDGeneratorFunc(func() string {
+		next++
+		return fmt.Sprintf("event-%d", next)
+	}))
+	Ok(t, err)
+	first := orderPlaced{f.New("OrderPlaced", 2), "order-1"}
+	second := orderPlaced{f.New("OrderPlaced", 2), "order-2"}
+
+	Equals(t, "event-1", first.ID())
+	Equals(t, "OrderPlaced", first.Type())
+	Equals(t, 2, first.Version())
+	Equals(t, now, first.OccurredOn())
+	Equals(t, "event-2", second.ID())
+}
+
+func TestNewEventFactory_NilClock(t *testing.T) {
+	_, err := domain.NewEventFactory(nil, domain.IDGeneratorFunc(func() string {
+		return ""
+	}))
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestNewBaseEvent(t *testing.T) {
+	before := time.Now()
+	first := domain.NewBaseEvent("OrderPlaced", 1)
+	second := domain.NewBaseEvent("OrderPlaced", 1)
+
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	Assert(t, uuid.MatchString(first.ID()), "unexpected id %s", first.ID())
+	Assert(t, first.ID() != second.ID(), "ids should be unique")
+	Assert(t, !first.OccurredOn().Before(before), "unexpected occurred on %v", first.OccurredOn())
+}
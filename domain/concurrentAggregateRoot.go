@@ -0,0 +1,105 @@
+package domain
+
+import "sync"
+
+// ConcurrentAggregateRoot is the variant of AggregateRoot whose event buffer can be shared between goroutines. All
+// its methods are synchronized, and DrainDomainEvents takes and clears the events atomically so that each event is
+// returned exactly once.
+type ConcurrentAggregateRoot struct {
+	mu sync.Mutex
+	ar AggregateRoot
+}
+
+// AndEventsFrom will append all the events from supplied aggregate root to the receiver, returning it. The version of
+// the receiver is increased by the number of appended events.
+func (ar *ConcurrentAggregateRoot) AndEventsFrom(other *ConcurrentAggregateRoot) *ConcurrentAggregateRoot {
+	events := other.DomainEvents()
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.ar.events = append(ar.ar.events, events...)
+	ar.ar.version += len(events)
+	return ar
+}
+
+// AndEvent will register the supplied event to the receiver aggregate root, returning it. The event is rejected if
+// the aggregate invariants are violated.
+func (ar *ConcurrentAggregateRoot) AndEvent(event Event) (*ConcurrentAggregateRoot, error) {
+	if _, err := ar.RegisterEvent(event); err != nil {
+		return ar, err
+	}
+	return ar, nil
+}
+
+// RegisterEvent will queue a new event to this aggregate root, returning the created event. The aggregate invariants
+// are checked first, while holding the lock of the event buffer: they must not invoke the receiver methods.
+func (ar *ConcurrentAggregateRoot) RegisterEvent(event Event) (Event, error) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.ar.RegisterEvent(event)
+}
+
+// AddInvariant will add the supplied invariant to the ones checked before registering an event.
+func (ar *ConcurrentAggregateRoot) AddInvariant(invariant Invariant) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.ar.AddInvariant(invariant)
+}
+
+// CheckInvariants will check all the invariants of the aggregate root, returning the violations as a single error.
+func (ar *ConcurrentAggregateRoot) CheckInvariants() error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.ar.CheckInvariants()
+}
+
+// DomainEvents will return a copy of internal events of receiver aggregate root.
+func (ar *ConcurrentAggregateRoot) DomainEvents() []Event {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.ar.DomainEvents()
+}
+
+// DrainDomainEvents will atomically return and clear the domain events of this aggregate root.
+func (ar *ConcurrentAggregateRoot) DrainDomainEvents() []Event {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.ar.DrainDomainEvents()
+}
+
+// ClearDomainEvents will clear the domain events of this aggregate root.
+func (ar *ConcurrentAggregateRoot) ClearDomainEvents() {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.ar.ClearDomainEvents()
+}
+
+// Version will return the current version of the aggregate root, including the uncommitted events.
+func (ar *ConcurrentAggregateRoot) Version() int {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.ar.Version()
+}
+
+// OriginalVersion will return the version of the aggregate root as it was loaded, excluding the uncommitted events.
+func (ar *ConcurrentAggregateRoot) OriginalVersion() int {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	return ar.ar.OriginalVersion()
+}
+
+// MarkCommitted will clear the domain events of this aggregate root, aligning the original version to the current
+// one. Events registered while the aggregate was being saved are cleared as well: use MarkCommittedEvents to commit
+// only the saved ones.
+func (ar *ConcurrentAggregateRoot) MarkCommitted() {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.ar.MarkCommitted()
+}
+
+// MarkCommittedEvents will clear the first n domain events of this aggregate root, increasing the original version by
+// n. The events registered after the committed ones, even while the aggregate was being saved, are kept.
+func (ar *ConcurrentAggregateRoot) MarkCommittedEvents(n int) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	ar.ar.MarkCommittedEvents(n)
+}
@@ -0,0 +1,89 @@
+package domain_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	. "github.com/maurofran/kit/testing"
+)
+
+func TestConcurrentAggregateRoot_DrainExactlyOnce(t *testing.T) {
+	ar := new(domain.ConcurrentAggregateRoot)
+	d := domain.NewDispatcher()
+	h := new(recordingHandler)
+	d.Subscribe(domain.AnyEventType, h)
+	var producers, consumers sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		producers.Add(1)
+		go func(i int) {
+			defer producers.Done()
+			for j := 0; j < 100; j++ {
+				ar.RegisterEvent(incremented{i*100 + j})
+			}
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for j := 0; j < 50; j++ {
+				domain.DispatchDrainedEventsOf(d, ar)
+			}
+		}()
+	}
+	producers.Wait()
+	consumers.Wait()
+	domain.DispatchDrainedEventsOf(d, ar)
+
+	Equals(t, 1000, h.count())
+	Equals(t, 1000, ar.Version())
+	seen := make(map[domain.Event]bool)
+	for _, event := range h.events {
+		Assert(t, !seen[event], "event %v dispatched twice", event)
+		seen[event] = true
+	}
+}
+
+func TestConcurrentAggregateRoot_Invariants(t *testing.T) {
+	ar := new(domain.ConcurrentAggregateRoot)
+	balance := -1
+	ar.AddInvariant(func() error {
+		return assert.IntMin(balance, 0, "balance")
+	})
+
+	_, err := ar.AndEvent(incremented{1})
+	Assert(t, domain.IsInvariantError(err), "should return an invariant error")
+	Equals(t, 0, len(ar.DomainEvents()))
+}
+
+func TestConcurrentAggregateRoot_MarkCommitted(t *testing.T) {
+	ar := new(domain.ConcurrentAggregateRoot)
+	ar.RegisterEvent(incremented{1})
+	other := new(domain.ConcurrentAggregateRoot)
+	other.RegisterEvent(incremented{2})
+	ar.AndEventsFrom(other)
+
+	Equals(t, []domain.Event{incremented{1}, incremented{2}}, ar.DomainEvents())
+
+	Equals(t, 2, ar.Version())
+
+	ar.MarkCommitted()
+
+	Equals(t, 0, len(ar.DrainDomainEvents()))
+	Equals(t, 2, ar.OriginalVersion())
+}
+
+func TestConcurrentAggregateRoot_MarkCommittedEvents(t *testing.T) {
+	ar := new(domain.ConcurrentAggregateRoot)
+	ar.RegisterEvent(incremented{1})
+	ar.RegisterEvent(incremented{2})
+	events := ar.DomainEvents()
+	ar.RegisterEvent(incremented{3})
+	ar.MarkCommittedEvents(len(events))
+
+	Equals(t, []domain.Event{incremented{3}}, ar.DomainEvents())
+	Equals(t, 2, ar.OriginalVersion())
+	Equals(t, 3, ar.Version())
+}
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// AnyEventType is the wildcard event type used to subscribe a handler to all the events.
+const AnyEventType = "*"
+
+// Handler is the interface implemented by domain event handlers.
+type Handler interface {
+	// Handle will process the supplied event, returning an error if processing failed.
+	Handle(Event) error
+}
+
+// HandlerFunc is an adapter allowing to use ordinary functions as event handlers.
+type HandlerFunc func(Event) error
+
+// Handle will invoke the receiver function with supplied event.
+func (f HandlerFunc) Handle(event Event) error {
+	return f(event)
+}
+
+// ErrorHandler is the function invoked when an asynchronously dispatched event could not be handled.
+type ErrorHandler func(Event, error)
+
+// EventDispatcher is the interface implemented by objects dispatching domain events to their handlers.
+type EventDispatcher interface {
+	// Dispatch will deliver the supplied events to the subscribed handlers.
+	Dispatch(events ...Event) error
+}
+
+// EventRecorder is the interface implemented by objects recording domain events, like aggregate roots.
+type EventRecorder interface {
+	DomainEvents() []Event
+	ClearDomainEvents()
+}
+
+// EventDrainer is the interface implemented by objects whose recorded domain events can be taken and cleared in a
+// single call, like ConcurrentAggregateRoot.
+type EventDrainer interface {
+	DrainDomainEvents() []Event
+}
+
+// DispatchDrainedEventsOf will atomically take the domain events recorded by supplied drainer and dispatch them, so
+// that concurrent callers never dispatch the same event twice. The drained events are returned with the dispatch
+// error, so that the caller can decide how to recover them.
+func DispatchDrainedEventsOf(dispatcher EventDispatcher, drainer EventDrainer) ([]Event, error) {
+	events := drainer.DrainDomainEvents()
+	return events, dispatcher.Dispatch(events...)
+}
+
+// DispatchEventsOf will dispatch the domain events recorded by supplied recorder, clearing them only if dispatch was
+// successful. Keeping the failed events is only meaningful with the synchronous Dispatcher, whose success means that
+// every handler succeeded: the AsyncDispatcher succeeds once the events are enqueued, so they are cleared before being
+// handled and the handler failures are only reported to its error handler.
+func DispatchEventsOf(dispatcher EventDispatcher, recorder EventRecorder) error {
+	if err := dispatcher.Dispatch(recorder.DomainEvents()...); err != nil {
+		return err
+	}
+	recorder.ClearDomainEvents()
+	return nil
+}
+
+type dispatchError struct {
+	errors []error
+}
+
+func (err dispatchError) Error() string {
+	messages := make([]string, len(err.errors))
+	for i, e := range err.errors {
+		messages[i] = e.Error()
+	}
+	return fmt.Sprintf("%d event handlers failed: %s", len(err.errors), strings.Join(messages, "; "))
+}
+
+// Unwrap will return the errors of the failed handlers.
+func (err dispatchError) Unwrap() []error {
+	return err.errors
+}
+
+// IsDispatchError verify if the supplied error is raised by failing event handlers.
+func IsDispatchError(err error) bool {
+	_, ok := err.(dispatchError)
+	return ok
+}
+
+// Dispatcher is a thread-safe dispatcher delivering domain events synchronously to handlers subscribed by event type.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewDispatcher will create a new dispatcher without subscribed handlers.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe will register the supplied handler for the events of supplied type. AnyEventType can be used to receive
+// all the events.
+func (d *Dispatcher) Subscribe(eventType string, handler Handler) error {
+	if err := assert.NotEmpty(eventType, "eventType"); err != nil {
+		return err
+	}
+	if err := assert.NotNil(handler, "handler"); err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[eventType] = append(d.handlers[eventType], handler)
+	return nil
+}
+
+// Dispatch will deliver the supplied events to all the subscribed handlers. A failing handler does not prevent the
+// other handlers from receiving the event; all the failures are returned as a single error.
+func (d *Dispatcher) Dispatch(events ...Event) error {
+	var errs []error
+	for _, event := range events {
+		for _, handler := range d.handlersFor(event) {
+			if err := handle(handler, event); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return dispatchError{errs}
+	}
+	return nil
+}
+
+func (d *Dispatcher) handlersFor(event Event) []Handler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	handlers := make([]Handler, 0, len(d.handlers[event.Type()])+len(d.handlers[AnyEventType]))
+	handlers = append(handlers, d.handlers[event.Type()]...)
+	return append(handlers, d.handlers[AnyEventType]...)
+}
+
+func handle(handler Handler, event Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler for event type %s panicked: %v", event.Type(), r)
+		}
+	}()
+	return handler.Handle(event)
+}
+
+// AsyncDispatcher is a dispatcher delivering domain events asynchronously through a pool of workers consuming a
+// bounded queue.
+type AsyncDispatcher struct {
+	dispatcher *Dispatcher
+	onError    ErrorHandler
+	queue      chan Event
+	mu         sync.RWMutex
+	closed     bool
+	wg         sync.WaitGroup
+}
+
+// NewAsyncDispatcher will create a new asynchronous dispatcher delivering events to the handlers of supplied
+// dispatcher using supplied number of workers. Dispatch blocks when queueSize events are waiting to be delivered.
+// Handler failures are reported to onError, that can be nil.
+func NewAsyncDispatcher(dispatcher *Dispatcher, workers, queueSize int, onError ErrorHandler) (*AsyncDispatcher, error) {
+	if err := assert.NotNil(dispatcher, "dispatcher"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(workers, 1, "workers"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(queueSize, 0, "queueSize"); err != nil {
+		return nil, err
+	}
+	d := &AsyncDispatcher{
+		dispatcher: dispatcher,
+		onError:    onError,
+		queue:      make(chan Event, queueSize),
+	}
+	d.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+	return d, nil
+}
+
+// Dispatch will enqueue the supplied events for delivery, blocking while the queue is full. No error is returned for
+// the handler failures, since they happen after returning: they are reported to the error handler.
+func (d *AsyncDispatcher) Dispatch(events ...Event) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if err := assert.StateNot(d.closed, "dispatcher is closed"); err != nil {
+		return err
+	}
+	for _, event := range events {
+		d.queue <- event
+	}
+	return nil
+}
+
+// Close will stop accepting new events, waiting for the queued ones to be delivered.
+func (d *AsyncDispatcher) Close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	d.mu.Unlock()
+	d.wg.Wait()
+}
+
+func (d *AsyncDispatcher) work() {
+	defer d.wg.Done()
+	for event := range d.queue {
+		if err := d.dispatcher.Dispatch(event); err != nil && d.onError != nil {
+			d.onError(event, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package domain_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	. "github.com/maurofran/kit/testing"
+)
+
+type recordingHandler struct {
+	mu     sync.Mutex
+	events []domain.Event
+}
+
+func (h *recordingHandler) Handle(event domain.Event) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+	return nil
+}
+
+func (h *recordingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.events)
+}
+
+func failing(err error) domain.HandlerFunc {
+	return func(domain.Event) error {
+		return err
+	}
+}
+
+func TestSubscribe_EmptyType(t *testing.T) {
+	err := domain.NewDispatcher().Subscribe("", new(recordingHandler))
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestDispatch_ByType(t *testing.T) {
+	d := domain.NewDispatcher()
+	typed := new(recordingHandler)
+	wildcard := new(recordingHandler)
+	d.Subscribe("Incremented", typed)
+	d.Subscribe(domain.AnyEventType, wildcard)
+	err := d.Dispatch(incremented{1}, unknown{})
+
+	Ok(t, err)
+	Equals(t, []domain.Event{incremented{1}}, typed.events)
+	Equals(t, []domain.Event{incremented{1}, unknown{}}, wildcard.events)
+}
+
+func TestDispatch_IsolatesFailures(t *testing.T) {
+	d := domain.NewDispatcher()
+	h := new(recordingHandler)
+	d.Subscribe("Incremented", failing(errors.New("boom")))
+	d.Subscribe("Incremented", domain.HandlerFunc(func(domain.Event) error {
+		panic("boom")
+	}))
+	d.Subscribe("Incremented", h)
+	err := d.Dispatch(incremented{1}, incremented{2})
+
+	Assert(t, domain.IsDispatchError(err), "should return a dispatch error")
+	Equals(t, 2, h.count())
+}
+
+func TestDispatchEventsOf_Success(t *testing.T) {
+	d := domain.NewDispatcher()
+	h := new(recordingHandler)
+	d.Subscribe("Incremented", h)
+	ar, _ := new(domain.AggregateRoot).AndEvent(incremented{1})
+	err := domain.DispatchEventsOf(d, ar)
+
+	Ok(t, err)
+	Equals(t, 1, h.count())
+	Equals(t, 0, len(ar.DomainEvents()))
+}
+
+func TestDispatchEventsOf_Failure(t *testing.T) {
+	d := domain.NewDispatcher()
+	d.Subscribe("Incremented", failing(errors.New("boom")))
+	ar, _ := new(domain.AggregateRoot).AndEvent(incremented{1})
+	err := domain.DispatchEventsOf(d, ar)
+
+	Assert(t, err != nil, "should return an error")
+	Equals(t, 1, len(ar.DomainEvents()))
+}
+
+func TestAsyncDispatcher(t *testing.T) {
+	d := domain.NewDispatcher()
+	h := new(recordingHandler)
+	d.Subscribe("Incremented", h)
+	d.Subscribe("Incremented", failing(errors.New("boom")))
+	var mu sync.Mutex
+	failures := 0
+	async, err := domain.NewAsyncDispatcher(d, 4, 2, func(domain.Event, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures++
+	})
+	Ok(t, err)
+	for i := 0; i < 100; i++ {
+		Ok(t, async.Dispatch(incremented{i}))
+	}
+	async.Close()
+
+	Equals(t, 100, h.count())
+	Equals(t, 100, failures)
+}
+
+func TestAsyncDispatcher_Closed(t *testing.T) {
+	async, _ := domain.NewAsyncDispatcher(domain.NewDispatcher(), 1, 1, nil)
+	async.Close()
+	err := async.Dispatch(incremented{1})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestNewAsyncDispatcher_InvalidWorkers(t *testing.T) {
+	_, err := domain.NewAsyncDispatcher(domain.NewDispatcher(), 0, 1, nil)
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
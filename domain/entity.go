@@ -0,0 +1,48 @@
+package domain
+
+import "reflect"
+
+// Identifiable is the interface implemented by objects exposing an identity of type ID.
+type Identifiable[ID comparable] interface {
+	ID() ID
+}
+
+// Entity is the struct embedded by domain objects whose equality is based on their identity instead of their
+// attributes. It implements assert.Equaler, so that assert.Equals compares entities by identity.
+type Entity[ID comparable] struct {
+	kind reflect.Type
+	id   ID
+}
+
+// entity is the interface implemented by the domain objects embedding an entity.
+type entity[ID comparable] interface {
+	entity() Entity[ID]
+}
+
+// NewEntity will create a new entity of the domain object of type T with supplied identity. The type of the domain
+// object takes part in the equality, so that entities of different types sharing an identity are not equal.
+func NewEntity[T any, ID comparable](id ID) Entity[ID] {
+	return Entity[ID]{reflect.TypeOf((*T)(nil)).Elem(), id}
+}
+
+// ID will return the identity of the entity.
+func (e Entity[ID]) ID() ID {
+	return e.id
+}
+
+// IsTransient will check if the entity has no identity yet.
+func (e Entity[ID]) IsTransient() bool {
+	var zero ID
+	return e.id == zero
+}
+
+// Equal will check if the supplied value, or the value it points to, is an entity of the same domain object type and
+// with the same identity of the receiver. Transient entities are never equal to other entities.
+func (e Entity[ID]) Equal(other interface{}) bool {
+	o, ok := other.(entity[ID])
+	return ok && !e.IsTransient() && o.entity() == e
+}
+
+func (e Entity[ID]) entity() Entity[ID] {
+	return e
+}
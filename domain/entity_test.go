@@ -0,0 +1,97 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	. "github.com/maurofran/kit/testing"
+)
+
+type orderID string
+
+type customerID string
+
+type order struct {
+	domain.Entity[orderID]
+	total int
+}
+
+type customer struct {
+	domain.Entity[customerID]
+}
+
+type invoice struct {
+	domain.Entity[orderID]
+}
+
+type money struct {
+	amount   int
+	currency string
+}
+
+func newMoney(amount int, currency string) (money, error) {
+	return domain.NewValueObject(money{amount, currency}, "money")
+}
+
+func (m money) IsValid() bool {
+	return m.amount >= 0 && len(m.currency) == 3
+}
+
+func TestEntity_EqualByIdentity(t *testing.T) {
+	a := order{domain.NewEntity[order](orderID("order-1")), 10}
+	b := order{domain.NewEntity[order](orderID("order-1")), 20}
+	c := order{domain.NewEntity[order](orderID("order-2")), 10}
+
+	Equals(t, true, a.Equal(b))
+	Equals(t, true, a.Equal(&b))
+	Equals(t, false, a.Equal(c))
+	Ok(t, assert.Equals(a, b, "order"))
+	Assert(t, assert.IsArgumentError(assert.Equals(a, c, "order")), "should return an argument error")
+}
+
+func TestEntity_DifferentIdentityTypes(t *testing.T) {
+	o := order{Entity: domain.NewEntity[order](orderID("1"))}
+	c := customer{domain.NewEntity[customer](customerID("1"))}
+
+	Equals(t, false, o.Equal(c))
+}
+
+func TestEntity_DifferentTypes(t *testing.T) {
+	o := order{Entity: domain.NewEntity[order](orderID("1"))}
+	i := invoice{domain.NewEntity[invoice](orderID("1"))}
+
+	Equals(t, false, o.Equal(i))
+	Equals(t, false, i.Equal(&o))
+	Assert(t, assert.IsArgumentError(assert.Equals(o, i, "order")), "should return an argument error")
+}
+
+func TestEntity_Transient(t *testing.T) {
+	a := order{}
+	b := order{}
+
+	Equals(t, true, a.IsTransient())
+	Equals(t, false, a.Equal(b))
+}
+
+func TestNewValueObject(t *testing.T) {
+	m, err := newMoney(10, "EUR")
+
+	Ok(t, err)
+	Equals(t, money{10, "EUR"}, m)
+
+	_, err = newMoney(-1, "EUR")
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestValueEqual(t *testing.T) {
+	a, _ := newMoney(10, "EUR")
+	b, _ := newMoney(10, "EUR")
+	c, _ := newMoney(10, "USD")
+
+	Equals(t, true, domain.ValueEqual(a, b))
+	Equals(t, false, domain.ValueEqual(a, c))
+	Equals(t, false, domain.ValueEqual(a, &b))
+	Ok(t, assert.Equals(a, b, "money"))
+}
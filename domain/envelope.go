@@ -0,0 +1,113 @@
+package domain
+
+import (
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/metadata"
+)
+
+// Well known metadata keys of an envelope.
+const (
+	// CorrelationIDKey is the metadata key of the identifier shared by all the events of the same flow.
+	CorrelationIDKey = "correlationId"
+	// CausationIDKey is the metadata key of the identifier of the event that caused the enveloped one.
+	CausationIDKey = "causationId"
+	// UserKey is the metadata key of the user that originated the event.
+	UserKey = "user"
+	// TenantKey is the metadata key of the tenant the event belongs to.
+	TenantKey = "tenant"
+)
+
+// Envelope is the immutable wrapper of a domain event, carrying its identity, position and metadata.
+type Envelope struct {
+	eventID  string
+	streamID string
+	sequence int
+	event    Event
+	metadata *metadata.Container
+}
+
+// NewEnvelope will create a new envelope for supplied event. When not present, the correlation id is initialized to
+// the event id, starting a new flow. A nil metadata is considered empty.
+func NewEnvelope(eventID, streamID string, sequence int, event Event, md *metadata.Container) (*Envelope, error) {
+	if err := assert.NotEmpty(eventID, "eventID"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(event, "event"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(sequence, 0, "sequence"); err != nil {
+		return nil, err
+	}
+	if md == nil {
+		md = metadata.Empty()
+	}
+	md = md.AndIfNotPresent(CorrelationIDKey, func() interface{} {
+		return eventID
+	})
+	return &Envelope{eventID: eventID, streamID: streamID, sequence: sequence, event: event, metadata: md}, nil
+}
+
+// Caused will create a new envelope for an event caused by the receiver one. The new envelope inherits receiver
+// metadata and correlation id, using receiver event id as causation id.
+func (e *Envelope) Caused(eventID, streamID string, sequence int, event Event) (*Envelope, error) {
+	md := e.metadata.And(CausationIDKey, e.eventID).And(CorrelationIDKey, e.CorrelationID())
+	return NewEnvelope(eventID, streamID, sequence, event, md)
+}
+
+// EventID will return the unique identifier of the enveloped event.
+func (e *Envelope) EventID() string {
+	return e.eventID
+}
+
+// StreamID will return the identifier of the stream the event belongs to.
+func (e *Envelope) StreamID() string {
+	return e.streamID
+}
+
+// Sequence will return the sequence number of the event inside its stream.
+func (e *Envelope) Sequence() int {
+	return e.sequence
+}
+
+// Event will return the enveloped event.
+func (e *Envelope) Event() Event {
+	return e.event
+}
+
+// Metadata will return the metadata of the event.
+func (e *Envelope) Metadata() *metadata.Container {
+	return e.metadata
+}
+
+// WithMetadata will return a new envelope with supplied metadata merged into receiver ones.
+func (e *Envelope) WithMetadata(entries map[string]interface{}) *Envelope {
+	res := *e
+	res.metadata = e.metadata.MergedWith(entries)
+	return &res
+}
+
+// CorrelationID will return the identifier shared by all the events of the same flow.
+func (e *Envelope) CorrelationID() string {
+	return e.stringValue(CorrelationIDKey)
+}
+
+// CausationID will return the identifier of the event that caused the enveloped one, empty if none.
+func (e *Envelope) CausationID() string {
+	return e.stringValue(CausationIDKey)
+}
+
+// User will return the user that originated the event, empty if unknown.
+func (e *Envelope) User() string {
+	return e.stringValue(UserKey)
+}
+
+// Tenant will return the tenant the event belongs to, empty if unknown.
+func (e *Envelope) Tenant() string {
+	return e.stringValue(TenantKey)
+}
+
+func (e *Envelope) stringValue(key string) string {
+	value, _ := e.metadata.Get(key)
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,60 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+	. "github.com/maurofran/kit/testing"
+)
+
+func TestNewEnvelope(t *testing.T) {
+	md := metadata.With(domain.UserKey, "john").And(domain.TenantKey, "acme")
+	e, err := domain.NewEnvelope("event-1", "stream-1", 1, incremented{1}, md)
+
+	Ok(t, err)
+	Equals(t, "event-1", e.EventID())
+	Equals(t, "stream-1", e.StreamID())
+	Equals(t, 1, e.Sequence())
+	Equals(t, incremented{1}, e.Event())
+	Equals(t, "event-1", e.CorrelationID())
+	Equals(t, "", e.CausationID())
+	Equals(t, "john", e.User())
+	Equals(t, "acme", e.Tenant())
+}
+
+func TestNewEnvelope_KeepsCorrelationID(t *testing.T) {
+	e, err := domain.NewEnvelope("event-1", "stream-1", 1, incremented{1}, metadata.With(domain.CorrelationIDKey, "c"))
+
+	Ok(t, err)
+	Equals(t, "c", e.CorrelationID())
+}
+
+func TestNewEnvelope_NilEvent(t *testing.T) {
+	_, err := domain.NewEnvelope("event-1", "stream-1", 1, nil, nil)
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestCaused(t *testing.T) {
+	parent, _ := domain.NewEnvelope("event-1", "stream-1", 1, incremented{1}, metadata.With(domain.UserKey, "john"))
+	child, err := parent.Caused("event-2", "stream-2", 4, incremented{2})
+	grandChild, _ := child.Caused("event-3", "stream-3", 1, incremented{3})
+
+	Ok(t, err)
+	Equals(t, "event-1", child.CorrelationID())
+	Equals(t, "event-1", child.CausationID())
+	Equals(t, "john", child.User())
+	Equals(t, "event-1", grandChild.CorrelationID())
+	Equals(t, "event-2", grandChild.CausationID())
+	Equals(t, "", parent.CausationID())
+}
+
+func TestWithMetadata(t *testing.T) {
+	e, _ := domain.NewEnvelope("event-1", "stream-1", 1, incremented{1}, nil)
+	m := e.WithMetadata(map[string]interface{}{domain.TenantKey: "acme"})
+
+	Equals(t, "acme", m.Tenant())
+	Equals(t, "", e.Tenant())
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// ApplyFunc is the function used to mutate the state of an aggregate when an event is applied.
+type ApplyFunc func(Event)
+
+// EventSourcedAggregateRoot is the parent struct of aggregates whose state is derived from their domain events.
+type EventSourcedAggregateRoot struct {
+	AggregateRoot
+	handlers map[string]ApplyFunc
+}
+
+// On will register the apply handler for supplied event type, replacing any previously registered handler.
+func (ar *EventSourcedAggregateRoot) On(eventType string, apply ApplyFunc) {
+	if ar.handlers == nil {
+		ar.handlers = make(map[string]ApplyFunc)
+	}
+	ar.handlers[eventType] = apply
+}
+
+// Apply will mutate the aggregate state with supplied event, registering it as a new uncommitted event. If the
+// resulting state violates the aggregate invariants, the event is rejected and the state is restored as it was, as
+// described by Change.
+func (ar *EventSourcedAggregateRoot) Apply(event Event) error {
+	apply, err := ar.handler(event)
+	if err != nil {
+		return err
+	}
+	_, err = ar.Change(event, func() {
+		apply(event)
+	})
+	return err
+}
+
+// LoadFromHistory will rebuild the aggregate state by replaying supplied events. Replayed events are not registered
+// as uncommitted events, and both current and original version are increased for each of them.
+func (ar *EventSourcedAggregateRoot) LoadFromHistory(history []Event) error {
+	for _, event := range history {
+		if err := ar.apply(event); err != nil {
+			return err
+		}
+		ar.version++
+		ar.originalVersion = ar.version
+	}
+	return nil
+}
+
+// RestoreVersion will set both current and original version to supplied one, discarding uncommitted events. It is
+// used when the aggregate state is restored from a snapshot instead of being replayed.
+func (ar *EventSourcedAggregateRoot) RestoreVersion(version int) {
+	ar.ClearDomainEvents()
+	ar.version = version
+	ar.originalVersion = version
+}
+
+func (ar *EventSourcedAggregateRoot) apply(event Event) error {
+	apply, err := ar.handler(event)
+	if err != nil {
+		return err
+	}
+	apply(event)
+	return nil
+}
+
+func (ar *EventSourcedAggregateRoot) handler(event Event) (ApplyFunc, error) {
+	if err := assert.NotNil(event, "event"); err != nil {
+		return nil, err
+	}
+	apply, ok := ar.handlers[event.Type()]
+	if err := assert.State(ok, fmt.Sprintf("no apply handler registered for event type %s", event.Type())); err != nil {
+		return nil, err
+	}
+	return apply, nil
+}
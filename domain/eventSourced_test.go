@@ -0,0 +1,96 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	. "github.com/maurofran/kit/testing"
+)
+
+type incremented struct {
+	amount int
+}
+
+func (e incremented) Type() string {
+	return "Incremented"
+}
+
+func (e incremented) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e incremented) Version() int {
+	return 1
+}
+
+type unknown struct {
+	incremented
+}
+
+func (e unknown) Type() string {
+	return "Unknown"
+}
+
+type counter struct {
+	domain.EventSourcedAggregateRoot
+	value int
+}
+
+func newCounter() *counter {
+	c := new(counter)
+	c.On("Incremented", func(event domain.Event) {
+		c.value += event.(incremented).amount
+	})
+	return c
+}
+
+func TestLoadFromHistory(t *testing.T) {
+	c := newCounter()
+	err := c.LoadFromHistory([]domain.Event{incremented{1}, incremented{2}})
+
+	Ok(t, err)
+	Equals(t, 3, c.value)
+	Equals(t, 2, c.Version())
+	Equals(t, 2, c.OriginalVersion())
+	Equals(t, 0, len(c.DomainEvents()))
+}
+
+func TestLoadFromHistory_MissingHandler(t *testing.T) {
+	err := newCounter().LoadFromHistory([]domain.Event{incremented{1}, unknown{}})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestApply(t *testing.T) {
+	c := newCounter()
+	c.LoadFromHistory([]domain.Event{incremented{1}})
+	err := c.Apply(incremented{5})
+
+	Ok(t, err)
+	Equals(t, 6, c.value)
+	Equals(t, 2, c.Version())
+	Equals(t, 1, c.OriginalVersion())
+	Equals(t, []domain.Event{incremented{5}}, c.DomainEvents())
+}
+
+func TestApply_MissingHandler(t *testing.T) {
+	c := newCounter()
+	err := c.Apply(unknown{})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+	Equals(t, 0, c.Version())
+	Equals(t, 0, len(c.DomainEvents()))
+}
+
+func TestMarkCommitted(t *testing.T) {
+	c := newCounter()
+	c.Apply(incremented{1})
+	c.Apply(incremented{2})
+	c.MarkCommitted()
+
+	Equals(t, 2, c.Version())
+	Equals(t, 2, c.OriginalVersion())
+	Equals(t, 0, len(c.DomainEvents()))
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"reflect"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// ValueObject is the interface implemented by value objects, domain objects without identity that are equal when
+// all their attributes are equal. Value objects should be immutable: declare them as structs with unexported fields,
+// pass them by value, and return a new value from every method changing them instead of mutating the receiver.
+type ValueObject interface {
+	assert.Validatable
+}
+
+// NewValueObject will validate the supplied value object, returning it if it's valid or an argument error in the
+// format '<argName> is not valid' if it's not. It is meant to be the last statement of value object constructors.
+func NewValueObject[T ValueObject](value T, argName string) (T, error) {
+	if err := assert.IsValid(value, argName); err != nil {
+		var zero T
+		return zero, err
+	}
+	return value, nil
+}
+
+// ValueEqual will check if the supplied values are structurally equal, meaning that they have the same type and all
+// their attributes are deeply equal.
+func ValueEqual(a, b interface{}) bool {
+	return reflect.TypeOf(a) == reflect.TypeOf(b) && reflect.DeepEqual(a, b)
+}
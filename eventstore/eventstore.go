@@ -0,0 +1,70 @@
+package eventstore
+
+import (
+	"fmt"
+
+	"github.com/maurofran/kit/domain"
+)
+
+const (
+	// AnyVersion can be used as expected version to disable the optimistic concurrency check.
+	AnyVersion = -1
+	// NoStream can be used as expected version to assert that the stream does not exist yet.
+	NoStream = 0
+)
+
+// Record is a domain event persisted in a stream.
+type Record struct {
+	// StreamID is the identifier of the stream the event belongs to.
+	StreamID string
+	// Version is the position of the event inside its stream, starting from 1.
+	Version int
+	// Position is the global position of the event inside the store, starting from 1.
+	Position int64
+	// Event is the persisted domain event.
+	Event domain.Event
+}
+
+// Store is the interface implemented by domain event stores.
+type Store interface {
+	// Append will append the supplied events to the stream, checking that the current version of the stream is equal
+	// to expected version. The new version of the stream is returned.
+	Append(streamID string, expectedVersion int, events ...domain.Event) (int, error)
+	// Load will retrieve the records of the stream, starting from supplied version included.
+	Load(streamID string, fromVersion int) ([]Record, error)
+	// ReadAll will retrieve all the records of the store, in global order.
+	ReadAll() ([]Record, error)
+}
+
+// Events will extract the domain events from the supplied records.
+func Events(records []Record) []domain.Event {
+	events := make([]domain.Event, len(records))
+	for i, record := range records {
+		events[i] = record.Event
+	}
+	return events
+}
+
+type concurrencyError struct {
+	streamID        string
+	expectedVersion int
+	actualVersion   int
+}
+
+func (err concurrencyError) Error() string {
+	return fmt.Sprintf("stream %s is at version %d, expected version %d", err.streamID, err.actualVersion,
+		err.expectedVersion)
+}
+
+// IsConcurrencyError verify if the supplied error is an optimistic concurrency error.
+func IsConcurrencyError(err error) bool {
+	_, ok := err.(concurrencyError)
+	return ok
+}
+
+func checkVersion(streamID string, expectedVersion, actualVersion int) error {
+	if expectedVersion != AnyVersion && expectedVersion != actualVersion {
+		return concurrencyError{streamID, expectedVersion, actualVersion}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package eventstore_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// The fake SQL driver runs the statements of the SQL event store in memory, emulating a database at the read
+// committed isolation level: reads see the committed changes and the ones of their own transaction, and the update
+// of es_position locks the row until the transaction ends. It exercises the concurrency path of the store without a
+// database driver.
+
+var (
+	fakeOnce sync.Once
+	fakeMu   sync.Mutex
+	fakeDBs  = make(map[string]*fakeDB)
+)
+
+type fakeEvent struct {
+	position  int64
+	streamID  string
+	version   int
+	eventType string
+	data      []byte
+}
+
+type fakeDB struct {
+	mu       sync.Mutex
+	lock     chan struct{}
+	waiting  int
+	streams  map[string]int
+	events   []fakeEvent
+	position int64
+}
+
+// waiters will return the number of transactions waiting for the es_position row lock.
+func (db *fakeDB) waiters() int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.waiting
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return &fakeConn{db: fakeDBs[name]}, nil
+}
+
+// openFakeDB will open a new empty database served by the fake driver.
+func openFakeDB(t *testing.T) (*sql.DB, *fakeDB) {
+	fakeOnce.Do(func() {
+		sql.Register("fakesql", fakeDriver{})
+	})
+	fake := &fakeDB{lock: make(chan struct{}, 1), streams: make(map[string]int)}
+	fakeMu.Lock()
+	fakeDBs[t.Name()] = fake
+	fakeMu.Unlock()
+	db, err := sql.Open("fakesql", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+type fakeConn struct {
+	db *fakeDB
+	tx *fakeTx
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.tx = &fakeTx{conn: c, streams: make(map[string]int), position: -1}
+	return c.tx, nil
+}
+
+type fakeTx struct {
+	conn     *fakeConn
+	locked   bool
+	streams  map[string]int
+	events   []fakeEvent
+	position int64
+}
+
+func (tx *fakeTx) Commit() error {
+	db := tx.conn.db
+	db.mu.Lock()
+	for streamID, version := range tx.streams {
+		db.streams[streamID] = version
+	}
+	db.events = append(db.events, tx.events...)
+	if tx.position >= 0 {
+		db.position = tx.position
+	}
+	db.mu.Unlock()
+	return tx.end()
+}
+
+func (tx *fakeTx) Rollback() error {
+	return tx.end()
+}
+
+func (tx *fakeTx) end() error {
+	if tx.locked {
+		<-tx.conn.db.lock
+	}
+	tx.conn.tx = nil
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	db, tx := s.conn.db, s.conn.tx
+	switch s.query {
+	case "UPDATE es_position SET position = position + ? WHERE id = 1":
+		if !tx.locked {
+			db.mu.Lock()
+			db.waiting++
+			db.mu.Unlock()
+			db.lock <- struct{}{}
+			tx.locked = true
+			db.mu.Lock()
+			db.waiting--
+			db.mu.Unlock()
+		}
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		if tx.position < 0 {
+			tx.position = db.position
+		}
+		tx.position += args[0].(int64)
+		return driver.RowsAffected(1), nil
+	case "INSERT INTO es_streams (stream_id, version) VALUES (?, ?)":
+		streamID := args[0].(string)
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		if _, ok := db.streams[streamID]; ok {
+			return nil, errors.New("unique constraint violated on es_streams")
+		}
+		tx.streams[streamID] = int(args[1].(int64))
+		return driver.RowsAffected(1), nil
+	case "UPDATE es_streams SET version = ? WHERE stream_id = ? AND version = ?":
+		streamID := args[1].(string)
+		db.mu.Lock()
+		defer db.mu.Unlock()
+		current, ok := tx.streams[streamID]
+		if !ok {
+			current = db.streams[streamID]
+		}
+		if current != int(args[2].(int64)) {
+			return driver.RowsAffected(0), nil
+		}
+		tx.streams[streamID] = int(args[0].(int64))
+		return driver.RowsAffected(1), nil
+	case "INSERT INTO es_events (position, stream_id, version, event_type, data) VALUES (?, ?, ?, ?, ?)":
+		tx.events = append(tx.events, fakeEvent{
+			position:  args[0].(int64),
+			streamID:  args[1].(string),
+			version:   int(args[2].(int64)),
+			eventType: args[3].(string),
+			data:      args[4].([]byte),
+		})
+		return driver.RowsAffected(1), nil
+	}
+	return nil, fmt.Errorf("unexpected statement %q", s.query)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	db, tx := s.conn.db, s.conn.tx
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	switch s.query {
+	case "SELECT version FROM es_streams WHERE stream_id = ?":
+		streamID := args[0].(string)
+		if tx != nil {
+			if version, ok := tx.streams[streamID]; ok {
+				return &fakeRows{values: [][]driver.Value{{int64(version)}}}, nil
+			}
+		}
+		if version, ok := db.streams[streamID]; ok {
+			return &fakeRows{values: [][]driver.Value{{int64(version)}}}, nil
+		}
+		return &fakeRows{}, nil
+	case "SELECT position FROM es_position WHERE id = 1":
+		position := db.position
+		if tx != nil && tx.position >= 0 {
+			position = tx.position
+		}
+		return &fakeRows{values: [][]driver.Value{{position}}}, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", s.query)
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
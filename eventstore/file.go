@@ -0,0 +1,703 @@
+package eventstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/domain"
+)
+
+// SyncPolicy is the policy flushing the appended events to the disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways flushes the appended events to the disk before returning from each append.
+	SyncAlways SyncPolicy = iota
+	// SyncNever leaves the flushing of the appended events to the operating system, unless Sync is called.
+	SyncNever
+)
+
+const (
+	segmentExt      = ".log"
+	compactExt      = ".compact"
+	frameHeaderSize = 8
+
+	kindEvent     byte = 1
+	kindTombstone byte = 2
+	flagCommit    byte = 0x80
+)
+
+type corruptionError struct {
+	segment string
+	offset  int64
+}
+
+func (err corruptionError) Error() string {
+	return fmt.Sprintf("segment %s is corrupted at offset %d", err.segment, err.offset)
+}
+
+// IsCorruptionError verify if the supplied error is caused by a corrupted segment file.
+func IsCorruptionError(err error) bool {
+	_, ok := err.(corruptionError)
+	return ok
+}
+
+type segment struct {
+	base int64
+	path string
+	file *os.File
+	size int64
+}
+
+type location struct {
+	streamID string
+	version  int
+	position int64
+	segment  *segment
+	offset   int64
+	size     int64
+}
+
+// FileStore is a thread-safe event store persisting events to disk without a database. Events are appended to a log
+// split in segment files named after the position of their first event, each event being framed with its length and
+// checksum. The index of the streams is kept in memory and rebuilt scanning the segments when the store is opened.
+//
+// The events appended at once are committed atomically: after a crash, the events whose append was not completed are
+// truncated from the last segment when the store is opened again.
+type FileStore struct {
+	mu          sync.RWMutex
+	dir         string
+	codec       codec.Codec
+	segmentSize int64
+	policy      SyncPolicy
+	segments    []*segment
+	records     []*location
+	streams     map[string][]*location
+	next        int64
+	closed      bool
+}
+
+// NewFileStore will open the event store persisted in supplied directory, creating it if needed. Events are encoded
+// with supplied codec, and a new segment is started when the current one would grow beyond segment size. The
+// supplied sync policy controls when the appended events are flushed to the disk.
+func NewFileStore(dir string, c codec.Codec, segmentSize int64, policy SyncPolicy) (*FileStore, error) {
+	if err := assert.NotEmpty(dir, "dir"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(c, "codec"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(segmentSize > 0, "segmentSize must be positive"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(policy == SyncAlways || policy == SyncNever, "policy is not valid"); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &FileStore{
+		dir:         dir,
+		codec:       c,
+		segmentSize: segmentSize,
+		policy:      policy,
+		streams:     make(map[string][]*location),
+		next:        1,
+	}
+	if err := s.recover(); err != nil {
+		s.closeSegments()
+		return nil, err
+	}
+	if len(s.segments) == 0 {
+		if err := s.roll(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Append will append the supplied events to the stream, checking that the current version of the stream is equal
+// to expected version. The new version of the stream is returned.
+func (s *FileStore) Append(streamID string, expectedVersion int, events ...domain.Event) (int, error) {
+	if err := assert.NotEmpty(streamID, "streamID"); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkOpen(); err != nil {
+		return 0, err
+	}
+	stream := s.streams[streamID]
+	if err := checkVersion(streamID, expectedVersion, len(stream)); err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		if err := assert.NotNil(event, "event"); err != nil {
+			return 0, err
+		}
+	}
+	if len(events) == 0 {
+		return len(stream), nil
+	}
+	var buf bytes.Buffer
+	locations := make([]*location, len(events))
+	for i, event := range events {
+		loc := &location{streamID: streamID, version: len(stream) + i + 1, position: s.next + int64(i)}
+		envelope, err := domain.NewEnvelope(strconv.FormatInt(loc.position, 10), streamID, loc.version, event, nil)
+		if err != nil {
+			return 0, err
+		}
+		body, err := s.codec.Marshal(envelope)
+		if err != nil {
+			return 0, err
+		}
+		kind := kindEvent
+		if i == len(events)-1 {
+			kind |= flagCommit
+		}
+		loc.offset = int64(buf.Len())
+		writeFrame(&buf, eventPayload(kind, loc, body))
+		loc.size = int64(buf.Len()) - loc.offset
+		locations[i] = loc
+	}
+	seg, offset, err := s.write(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+	for _, loc := range locations {
+		loc.segment = seg
+		loc.offset += offset
+		s.index(loc)
+	}
+	return len(s.streams[streamID]), nil
+}
+
+// Load will retrieve the records of the stream, starting from supplied version included.
+func (s *FileStore) Load(streamID string, fromVersion int) ([]Record, error) {
+	if err := assert.NotEmpty(streamID, "streamID"); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	if fromVersion < 1 {
+		fromVersion = 1
+	}
+	stream := s.streams[streamID]
+	records := make([]Record, 0)
+	for i := fromVersion - 1; i < len(stream); i++ {
+		record, err := s.read(stream[i])
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ReadAll will retrieve all the records of the store, in global order.
+func (s *FileStore) ReadAll() ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	records := make([]Record, len(s.records))
+	for i, loc := range s.records {
+		record, err := s.read(loc)
+		if err != nil {
+			return nil, err
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// Stream will return an iterator over the records satisfying supplied filter. Forward iterations deliver the records
+// appended while iterating as well, backward iterations start from the last record at the time of the call.
+func (s *FileStore) Stream(ctx context.Context, filter Filter) (Iterator, error) {
+	if err := assert.NotNil(ctx, "ctx"); err != nil {
+		return nil, err
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if err := s.checkOpen(); err != nil {
+		return nil, err
+	}
+	if filter.Direction == Backward {
+		last := s.next - 1
+		if filter.From > 0 && filter.From < last {
+			last = filter.From
+		}
+		return newCursor(ctx, filter, int(last), func(next int, limit int) ([]Record, int, bool, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			if err := s.checkOpen(); err != nil {
+				return nil, next, true, err
+			}
+			candidates := s.candidates(filter.StreamID)
+			i := sort.Search(len(candidates), func(i int) bool { return candidates[i].position > int64(next) })
+			batch := make([]Record, 0, limit)
+			for ; i > 0 && len(batch) < limit; i-- {
+				next = int(candidates[i-1].position) - 1
+				record, err := s.read(candidates[i-1])
+				if err != nil {
+					return nil, next, true, err
+				}
+				if filter.Matches(record) {
+					batch = append(batch, record)
+				}
+			}
+			return batch, next, i == 0, nil
+		}), nil
+	}
+	return newCursor(ctx, filter, int(filter.From), func(next int, limit int) ([]Record, int, bool, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		if err := s.checkOpen(); err != nil {
+			return nil, next, true, err
+		}
+		candidates := s.candidates(filter.StreamID)
+		i := sort.Search(len(candidates), func(i int) bool { return candidates[i].position >= int64(next) })
+		batch := make([]Record, 0, limit)
+		for ; i < len(candidates) && len(batch) < limit; i++ {
+			next = int(candidates[i].position) + 1
+			record, err := s.read(candidates[i])
+			if err != nil {
+				return nil, next, true, err
+			}
+			if filter.Matches(record) {
+				batch = append(batch, record)
+			}
+		}
+		return batch, next, i >= len(candidates), nil
+	}), nil
+}
+
+// Delete will remove the stream with supplied id, whose events are no more retrieved. The disk space used by the
+// events is reclaimed by Compact. Appending to a deleted stream starts it again from version 1.
+func (s *FileStore) Delete(streamID string) error {
+	if err := assert.NotEmpty(streamID, "streamID"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	if _, ok := s.streams[streamID]; !ok {
+		return nil
+	}
+	var buf bytes.Buffer
+	writeFrame(&buf, tombstonePayload(s.next, streamID))
+	if _, _, err := s.write(buf.Bytes()); err != nil {
+		return err
+	}
+	s.unindex(s.next, streamID)
+	return nil
+}
+
+// Compact will rewrite the segments preceding the current one, dropping the events of the deleted streams. Segments
+// left without events are removed.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	live := make(map[*segment][]*location)
+	for _, loc := range s.records {
+		live[loc.segment] = append(live[loc.segment], loc)
+	}
+	current := len(s.segments) - 1
+	kept := make([]*segment, 0, len(s.segments))
+	for i, seg := range s.segments[:current] {
+		if err := s.compact(seg, live[seg]); err != nil {
+			s.segments = append(kept, s.segments[i:]...)
+			return err
+		}
+		if len(live[seg]) > 0 {
+			kept = append(kept, seg)
+		}
+	}
+	s.segments = append(kept, s.segments[current])
+	return syncDir(s.dir)
+}
+
+// Sync will flush the appended events to the disk.
+func (s *FileStore) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	return s.segments[len(s.segments)-1].file.Sync()
+}
+
+// Close will flush the appended events to the disk and release the segment files. The store can not be used after it
+// is closed.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	err := s.segments[len(s.segments)-1].file.Sync()
+	if closeErr := s.closeSegments(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s *FileStore) checkOpen() error {
+	return assert.State(!s.closed, "store is closed")
+}
+
+func (s *FileStore) closeSegments() error {
+	var err error
+	for _, seg := range s.segments {
+		if closeErr := seg.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (s *FileStore) candidates(streamID string) []*location {
+	if streamID == "" {
+		return s.records
+	}
+	return s.streams[streamID]
+}
+
+func (s *FileStore) index(loc *location) {
+	s.records = append(s.records, loc)
+	s.streams[loc.streamID] = append(s.streams[loc.streamID], loc)
+	if loc.position >= s.next {
+		s.next = loc.position + 1
+	}
+}
+
+func (s *FileStore) unindex(position int64, streamID string) {
+	if position >= s.next {
+		s.next = position + 1
+	}
+	delete(s.streams, streamID)
+	records := make([]*location, 0, len(s.records))
+	for _, loc := range s.records {
+		if loc.streamID != streamID {
+			records = append(records, loc)
+		}
+	}
+	s.records = records
+}
+
+// recover will rebuild the index scanning the segments, truncating the uncommitted frames of the last one.
+func (s *FileStore) recover() error {
+	leftovers, err := filepath.Glob(filepath.Join(s.dir, "*"+compactExt))
+	if err != nil {
+		return err
+	}
+	for _, leftover := range leftovers {
+		if err := os.Remove(leftover); err != nil {
+			return err
+		}
+	}
+	names, err := filepath.Glob(filepath.Join(s.dir, "*"+segmentExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		base, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(name), segmentExt), 10, 64)
+		if err != nil {
+			return corruptionError{filepath.Base(name), 0}
+		}
+		file, err := os.OpenFile(name, os.O_RDWR, 0)
+		if err != nil {
+			return err
+		}
+		seg := &segment{base: base, path: name, file: file}
+		s.segments = append(s.segments, seg)
+		if base > s.next {
+			s.next = base
+		}
+		if err := s.scan(seg, i == len(names)-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) scan(seg *segment, last bool) error {
+	data, err := os.ReadFile(seg.path)
+	if err != nil {
+		return err
+	}
+	var pending []*location
+	var offset, committed int64
+	for offset < int64(len(data)) {
+		payload, n, ok := parseFrame(data[offset:])
+		if !ok {
+			break
+		}
+		switch payload[0] &^ flagCommit {
+		case kindEvent:
+			loc, _, ok := parseEvent(payload)
+			if !ok {
+				return corruptionError{filepath.Base(seg.path), offset}
+			}
+			loc.segment, loc.offset, loc.size = seg, offset, n
+			pending = append(pending, loc)
+		case kindTombstone:
+			position, streamID, ok := parseTombstone(payload)
+			if !ok {
+				return corruptionError{filepath.Base(seg.path), offset}
+			}
+			s.unindex(position, streamID)
+		default:
+			return corruptionError{filepath.Base(seg.path), offset}
+		}
+		offset += n
+		if payload[0]&flagCommit != 0 {
+			for _, loc := range pending {
+				s.index(loc)
+			}
+			pending = nil
+			committed = offset
+		}
+	}
+	if committed < int64(len(data)) {
+		if !last {
+			return corruptionError{filepath.Base(seg.path), committed}
+		}
+		if err := seg.file.Truncate(committed); err != nil {
+			return err
+		}
+		if err := seg.file.Sync(); err != nil {
+			return err
+		}
+	}
+	seg.size = committed
+	return nil
+}
+
+// write will append the supplied frames to the current segment, rolling it if full. The segment the frames are
+// written to and their offset are returned.
+func (s *FileStore) write(data []byte) (*segment, int64, error) {
+	seg := s.segments[len(s.segments)-1]
+	if seg.size > 0 && seg.size+int64(len(data)) > s.segmentSize {
+		if err := s.roll(); err != nil {
+			return nil, 0, err
+		}
+		seg = s.segments[len(s.segments)-1]
+	}
+	offset := seg.size
+	if _, err := seg.file.WriteAt(data, offset); err != nil {
+		seg.file.Truncate(offset)
+		return nil, 0, err
+	}
+	if s.policy == SyncAlways {
+		if err := seg.file.Sync(); err != nil {
+			seg.file.Truncate(offset)
+			return nil, 0, err
+		}
+	}
+	seg.size += int64(len(data))
+	return seg, offset, nil
+}
+
+// roll will seal the current segment, if any, starting a new one named after the next position.
+func (s *FileStore) roll() error {
+	if len(s.segments) > 0 {
+		if err := s.segments[len(s.segments)-1].file.Sync(); err != nil {
+			return err
+		}
+	}
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.next, segmentExt))
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	s.segments = append(s.segments, &segment{base: s.next, path: name, file: file})
+	return syncDir(s.dir)
+}
+
+// compact will rewrite the supplied segment keeping the frames of the live locations only.
+func (s *FileStore) compact(seg *segment, locations []*location) error {
+	if len(locations) == 0 {
+		if err := seg.file.Close(); err != nil {
+			return err
+		}
+		return os.Remove(seg.path)
+	}
+	var size int64
+	for _, loc := range locations {
+		size += loc.size
+	}
+	if size == seg.size {
+		return nil
+	}
+	var buf bytes.Buffer
+	offsets := make([]int64, len(locations))
+	for i, loc := range locations {
+		data := make([]byte, loc.size)
+		if _, err := seg.file.ReadAt(data, loc.offset); err != nil {
+			return err
+		}
+		payload, _, ok := parseFrame(data)
+		if !ok {
+			return corruptionError{filepath.Base(seg.path), loc.offset}
+		}
+		payload[0] |= flagCommit
+		offsets[i] = int64(buf.Len())
+		writeFrame(&buf, payload)
+	}
+	tmp := seg.path + compactExt
+	file, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(buf.Bytes()); err == nil {
+		err = file.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, seg.path)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	seg.file.Close()
+	seg.file, seg.size = file, int64(buf.Len())
+	for i, loc := range locations {
+		loc.offset = offsets[i]
+	}
+	return nil
+}
+
+func (s *FileStore) read(loc *location) (Record, error) {
+	data := make([]byte, loc.size)
+	if _, err := loc.segment.file.ReadAt(data, loc.offset); err != nil {
+		return Record{}, err
+	}
+	payload, _, ok := parseFrame(data)
+	if !ok {
+		return Record{}, corruptionError{filepath.Base(loc.segment.path), loc.offset}
+	}
+	_, body, _ := parseEvent(payload)
+	envelope, err := s.codec.Unmarshal(body)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{StreamID: loc.streamID, Version: loc.version, Position: loc.position, Event: envelope.Event()}, nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// writeFrame will write the supplied payload prefixed by its length and checksum.
+func writeFrame(buf *bytes.Buffer, payload []byte) {
+	var header [frameHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+	buf.Write(header[:])
+	buf.Write(payload)
+}
+
+// parseFrame will read the payload of the frame at the beginning of data, returning the size of the whole frame. The
+// frame is not valid if torn or if its checksum does not match.
+func parseFrame(data []byte) ([]byte, int64, bool) {
+	if len(data) < frameHeaderSize {
+		return nil, 0, false
+	}
+	length := int64(binary.LittleEndian.Uint32(data[:4]))
+	if length == 0 || int64(len(data)-frameHeaderSize) < length {
+		return nil, 0, false
+	}
+	payload := data[frameHeaderSize : frameHeaderSize+length]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(data[4:frameHeaderSize]) {
+		return nil, 0, false
+	}
+	return payload, frameHeaderSize + length, true
+}
+
+func eventPayload(kind byte, loc *location, body []byte) []byte {
+	payload := []byte{kind}
+	payload = appendUvarint(payload, uint64(loc.position))
+	payload = appendUvarint(payload, uint64(loc.version))
+	payload = appendUvarint(payload, uint64(len(loc.streamID)))
+	payload = append(payload, loc.streamID...)
+	return append(payload, body...)
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutUvarint(buf[:], value)]...)
+}
+
+func tombstonePayload(position int64, streamID string) []byte {
+	payload := []byte{kindTombstone | flagCommit}
+	payload = appendUvarint(payload, uint64(position))
+	payload = appendUvarint(payload, uint64(len(streamID)))
+	return append(payload, streamID...)
+}
+
+// parseEvent will split the supplied event payload in the location of its header and the codec encoded body.
+func parseEvent(payload []byte) (*location, []byte, bool) {
+	data := payload[1:]
+	position, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, nil, false
+	}
+	data = data[n:]
+	version, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, nil, false
+	}
+	data = data[n:]
+	length, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < length {
+		return nil, nil, false
+	}
+	streamID := string(data[n : n+int(length)])
+	return &location{streamID: streamID, version: int(version), position: int64(position)}, data[n+int(length):], true
+}
+
+func parseTombstone(payload []byte) (int64, string, bool) {
+	data := payload[1:]
+	position, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, "", false
+	}
+	data = data[n:]
+	length, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < length {
+		return 0, "", false
+	}
+	return int64(position), string(data[n : n+int(length)]), true
+}
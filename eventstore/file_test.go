@@ -0,0 +1,195 @@
+package eventstore_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/eventstore"
+	. "github.com/maurofran/kit/testing"
+)
+
+type fileEvent struct {
+	Name string
+}
+
+func (e fileEvent) Type() string {
+	return e.Name
+}
+
+func (e fileEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e fileEvent) Version() int {
+	return 1
+}
+
+func openFileStore(t *testing.T, dir string, segmentSize int64) *eventstore.FileStore {
+	r := codec.NewRegistry()
+	r.Register(fileEvent{"e1"}, fileEvent{"e2"}, fileEvent{"e3"}, fileEvent{"e4"}, fileEvent{"e5"})
+	c, _ := codec.NewJSONCodec(r)
+	s, err := eventstore.NewFileStore(dir, c, segmentSize, eventstore.SyncAlways)
+	Ok(t, err)
+	return s
+}
+
+func aFileStore(t *testing.T, dir string, segmentSize int64) *eventstore.FileStore {
+	s := openFileStore(t, dir, segmentSize)
+	s.Append("stream-1", eventstore.NoStream, fileEvent{"e1"}, fileEvent{"e2"})
+	s.Append("stream-2", eventstore.NoStream, fileEvent{"e3"})
+	s.Append("stream-1", 2, fileEvent{"e4"})
+	return s
+}
+
+func segments(t *testing.T, dir string) []string {
+	names, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	Ok(t, err)
+	return names
+}
+
+func TestFileStore_Append(t *testing.T) {
+	s := aFileStore(t, t.TempDir(), 1<<20)
+	defer s.Close()
+	version, err := s.Append("stream-1", 3, fileEvent{"e5"})
+
+	Ok(t, err)
+	Equals(t, 4, version)
+	_, err = s.Append("stream-1", 3, fileEvent{"e5"})
+	Assert(t, eventstore.IsConcurrencyError(err), "should return a concurrency error")
+}
+
+func TestFileStore_Load(t *testing.T) {
+	s := aFileStore(t, t.TempDir(), 1<<20)
+	defer s.Close()
+	records, err := s.Load("stream-1", 2)
+
+	Ok(t, err)
+	Equals(t, []eventstore.Record{
+		{StreamID: "stream-1", Version: 2, Position: 2, Event: fileEvent{"e2"}},
+		{StreamID: "stream-1", Version: 3, Position: 4, Event: fileEvent{"e4"}},
+	}, records)
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	aFileStore(t, dir, 64).Close()
+	s := openFileStore(t, dir, 64)
+	defer s.Close()
+
+	Assert(t, len(segments(t, dir)) > 1, "should roll the segments")
+	records, err := s.ReadAll()
+	Ok(t, err)
+	Equals(t, []string{"e1", "e2", "e3", "e4"}, types(records))
+	version, err := s.Append("stream-2", 1, fileEvent{"e5"})
+	Ok(t, err)
+	Equals(t, 2, version)
+	records, _ = s.Load("stream-2", 1)
+	Equals(t, int64(5), records[1].Position)
+}
+
+func TestFileStore_TornWrite(t *testing.T) {
+	dir := t.TempDir()
+	aFileStore(t, dir, 1<<20).Close()
+	names := segments(t, dir)
+	file, _ := os.OpenFile(names[len(names)-1], os.O_WRONLY|os.O_APPEND, 0)
+	file.Write([]byte{42, 0, 0, 0, 1, 2, 3})
+	file.Close()
+	s := openFileStore(t, dir, 1<<20)
+	defer s.Close()
+
+	records, err := s.ReadAll()
+	Ok(t, err)
+	Equals(t, []string{"e1", "e2", "e3", "e4"}, types(records))
+	version, err := s.Append("stream-2", 1, fileEvent{"e5"})
+	Ok(t, err)
+	Equals(t, 2, version)
+}
+
+func TestFileStore_CorruptedSegment(t *testing.T) {
+	dir := t.TempDir()
+	aFileStore(t, dir, 64).Close()
+	names := segments(t, dir)
+	file, _ := os.OpenFile(names[0], os.O_WRONLY, 0)
+	file.WriteAt([]byte{0xff}, 10)
+	file.Close()
+	r := codec.NewRegistry()
+	c, _ := codec.NewJSONCodec(r)
+	_, err := eventstore.NewFileStore(dir, c, 64, eventstore.SyncAlways)
+
+	Assert(t, eventstore.IsCorruptionError(err), "should return a corruption error")
+}
+
+func TestFileStore_DeleteAndCompact(t *testing.T) {
+	dir := t.TempDir()
+	s := aFileStore(t, dir, 64)
+	before := len(segments(t, dir))
+
+	Ok(t, s.Delete("stream-1"))
+	records, _ := s.Load("stream-1", 1)
+	Equals(t, 0, len(records))
+	Ok(t, s.Compact())
+	Assert(t, len(segments(t, dir)) < before, "should remove the empty segments")
+	records, _ = s.ReadAll()
+	Equals(t, []string{"e3"}, types(records))
+	version, err := s.Append("stream-1", eventstore.NoStream, fileEvent{"e5"})
+	Ok(t, err)
+	Equals(t, 1, version)
+	s.Close()
+
+	s = openFileStore(t, dir, 64)
+	defer s.Close()
+	records, err = s.ReadAll()
+	Ok(t, err)
+	Equals(t, []string{"e3", "e5"}, types(records))
+	Equals(t, int64(6), records[1].Position)
+}
+
+func TestFileStore_Stream(t *testing.T) {
+	s := aFileStore(t, t.TempDir(), 64)
+	defer s.Close()
+
+	Equals(t, []string{"e4", "e2", "e1"}, stream(t, s, eventstore.Filter{
+		StreamID: "stream-1", Direction: eventstore.Backward, BatchSize: 1,
+	}))
+	Equals(t, []string{"e2", "e3"}, stream(t, s, eventstore.Filter{From: 2, Types: []string{"e2", "e3"}}))
+}
+
+func TestFileStore_Closed(t *testing.T) {
+	s := aFileStore(t, t.TempDir(), 1<<20)
+	s.Close()
+	_, err := s.Load("stream-1", 1)
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+	_, err = s.Stream(context.Background(), eventstore.Filter{})
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestFileStore_CompactTwice(t *testing.T) {
+	dir := t.TempDir()
+	s := openFileStore(t, dir, 450)
+	s.Append("stream-a", eventstore.NoStream, fileEvent{"e1"})
+	s.Append("stream-b", eventstore.NoStream, fileEvent{"e2"})
+	s.Append("stream-c", eventstore.NoStream, fileEvent{"e3"})
+	s.Append("stream-c", 1, fileEvent{"e4"})
+
+	Ok(t, s.Delete("stream-a"))
+	Ok(t, s.Compact())
+	Ok(t, s.Delete("stream-b"))
+	Ok(t, s.Compact())
+	s.Append("stream-c", 2, fileEvent{"e5"})
+	Ok(t, s.Compact())
+	Ok(t, s.Close())
+
+	s = openFileStore(t, dir, 450)
+	defer s.Close()
+	records, err := s.ReadAll()
+	Ok(t, err)
+	Equals(t, []string{"e3", "e4", "e5"}, types(records))
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "*.compact"))
+	Equals(t, 0, len(leftovers))
+}
@@ -0,0 +1,240 @@
+package eventstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Direction is the order records are iterated in.
+type Direction int
+
+const (
+	// Forward iterates records from the oldest to the newest.
+	Forward Direction = iota
+	// Backward iterates records from the newest to the oldest.
+	Backward
+)
+
+// DefaultBatchSize is the number of records fetched at once by iterators when the filter does not specify it.
+const DefaultBatchSize = 100
+
+// Filter is the set of criteria selecting the records to iterate. The zero value selects all the records of the
+// store, in forward order.
+type Filter struct {
+	// StreamID restricts the iteration to the records of a stream, all the streams are iterated when empty.
+	StreamID string
+	// Direction is the order of the iteration.
+	Direction Direction
+	// From is the global position the iteration starts from, included. When zero, the iteration starts from the
+	// first record if forward or from the last one if backward.
+	From int64
+	// Types restricts the iteration to the events of supplied types, all the types are iterated when empty.
+	Types []string
+	// Since restricts the iteration to the events occurred from supplied instant, included.
+	Since time.Time
+	// Until restricts the iteration to the events occurred before supplied instant, excluded.
+	Until time.Time
+	// BatchSize is the maximum number of records fetched from the store at once, DefaultBatchSize when zero.
+	BatchSize int
+}
+
+// Matches will check if the supplied record satisfies the filter criteria.
+func (f Filter) Matches(record Record) bool {
+	if f.StreamID != "" && record.StreamID != f.StreamID {
+		return false
+	}
+	if f.From > 0 {
+		if f.Direction == Forward && record.Position < f.From {
+			return false
+		}
+		if f.Direction == Backward && record.Position > f.From {
+			return false
+		}
+	}
+	if len(f.Types) > 0 && !f.handles(record.Event.Type()) {
+		return false
+	}
+	occurredOn := record.Event.OccurredOn()
+	if !f.Since.IsZero() && occurredOn.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !occurredOn.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+func (f Filter) handles(eventType string) bool {
+	for _, t := range f.Types {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) batchSize() int {
+	if f.BatchSize == 0 {
+		return DefaultBatchSize
+	}
+	return f.BatchSize
+}
+
+func (f Filter) validate() error {
+	if err := assert.Condition(f.Direction == Forward || f.Direction == Backward, "direction is not valid"); err != nil {
+		return err
+	}
+	if err := assert.Condition(f.From >= 0, "from must not be negative"); err != nil {
+		return err
+	}
+	return assert.IntMin(f.BatchSize, 0, "batchSize")
+}
+
+// Iterator is a cursor over the records of a store. Records are fetched lazily in batches while the cursor advances,
+// so that a slow consumer never causes the store to be read ahead of its pace.
+//
+//	it, err := eventstore.Stream(ctx, store, eventstore.Filter{Types: []string{"Created"}})
+//	if err != nil {
+//		return err
+//	}
+//	defer it.Close()
+//	for it.Next() {
+//		record := it.Record()
+//		...
+//	}
+//	return it.Err()
+type Iterator interface {
+	// Next will advance the iterator to the next record, returning false when there are no more records, the
+	// iterator is closed or an error occurred.
+	Next() bool
+	// Record will return the record the iterator is positioned on.
+	Record() Record
+	// Err will return the error stopping the iteration, if any. The context error is returned if the context of the
+	// iteration is done.
+	Err() error
+	// Close will release the iterator resources. Next will return false after the iterator is closed.
+	Close() error
+}
+
+// Streamer is the interface implemented by the stores able to iterate their records.
+type Streamer interface {
+	// Stream will return an iterator over the records satisfying supplied filter. The iteration is stopped as soon as
+	// the supplied context is done.
+	Stream(ctx context.Context, filter Filter) (Iterator, error)
+}
+
+// Stream will return an iterator over the records of the supplied store satisfying the filter. If the store is not a
+// Streamer, all its records are read at once and filtered in memory.
+func Stream(ctx context.Context, store Store, filter Filter) (Iterator, error) {
+	if err := assert.NotNil(store, "store"); err != nil {
+		return nil, err
+	}
+	if streamer, ok := store.(Streamer); ok {
+		return streamer.Stream(ctx, filter)
+	}
+	if err := assert.NotNil(ctx, "ctx"); err != nil {
+		return nil, err
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	records, err := store.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	get := func(i int) Record {
+		return records[i]
+	}
+	return newCursor(ctx, filter, 0, func(next int, limit int) ([]Record, int, bool, error) {
+		batch, next, done := fetch(get, len(records), next, limit, filter)
+		return batch, next, done, nil
+	}), nil
+}
+
+// Collect will drain the supplied iterator, returning all its records.
+func Collect(it Iterator) ([]Record, error) {
+	defer it.Close()
+	records := make([]Record, 0)
+	for it.Next() {
+		records = append(records, it.Record())
+	}
+	return records, it.Err()
+}
+
+// fetchFunc is the function retrieving from the store at most limit records satisfying the filter, starting from the
+// next candidate in the iteration order. Candidates are identified by a cursor whose meaning is up to the store: the
+// cursor of the candidate following the last one examined and a flag reporting the exhaustion of the candidates are
+// returned as well.
+type fetchFunc func(next int, limit int) ([]Record, int, bool, error)
+
+type cursor struct {
+	ctx     context.Context
+	filter  Filter
+	fetch   fetchFunc
+	next    int
+	buffer  []Record
+	current Record
+	err     error
+	done    bool
+}
+
+func newCursor(ctx context.Context, filter Filter, next int, fetch fetchFunc) *cursor {
+	return &cursor{ctx: ctx, filter: filter, fetch: fetch, next: next}
+}
+
+func (c *cursor) Next() bool {
+	if c.err != nil {
+		return false
+	}
+	if err := c.ctx.Err(); err != nil {
+		c.err = err
+		c.buffer = nil
+		return false
+	}
+	for len(c.buffer) == 0 {
+		if c.done {
+			return false
+		}
+		buffer, next, done, err := c.fetch(c.next, c.filter.batchSize())
+		if err != nil {
+			c.err = err
+			return false
+		}
+		c.buffer, c.next, c.done = buffer, next, done
+	}
+	c.current = c.buffer[0]
+	c.buffer = c.buffer[1:]
+	return true
+}
+
+func (c *cursor) Record() Record {
+	return c.current
+}
+
+func (c *cursor) Err() error {
+	return c.err
+}
+
+func (c *cursor) Close() error {
+	c.done = true
+	c.buffer = nil
+	return nil
+}
+
+// fetch will select at most limit records satisfying the filter among the total candidates ordered by position,
+// starting from the next one in the iteration order.
+func fetch(get func(int) Record, total int, next int, limit int, filter Filter) ([]Record, int, bool) {
+	batch := make([]Record, 0, limit)
+	for ; next < total && len(batch) < limit; next++ {
+		i := next
+		if filter.Direction == Backward {
+			i = total - 1 - next
+		}
+		if record := get(i); filter.Matches(record) {
+			batch = append(batch, record)
+		}
+	}
+	return batch, next, next >= total
+}
@@ -0,0 +1,124 @@
+package eventstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/eventstore"
+	. "github.com/maurofran/kit/testing"
+)
+
+type timedEvent struct {
+	name       string
+	occurredOn time.Time
+}
+
+func (e timedEvent) Type() string {
+	return e.name
+}
+
+func (e timedEvent) OccurredOn() time.Time {
+	return e.occurredOn
+}
+
+func (e timedEvent) Version() int {
+	return 1
+}
+
+// plainStore hides the Stream method of the wrapped store.
+type plainStore struct {
+	eventstore.Store
+}
+
+func types(records []eventstore.Record) []string {
+	names := make([]string, len(records))
+	for i, record := range records {
+		names[i] = record.Event.Type()
+	}
+	return names
+}
+
+func stream(t *testing.T, store eventstore.Store, filter eventstore.Filter) []string {
+	it, err := eventstore.Stream(context.Background(), store, filter)
+	Ok(t, err)
+	records, err := eventstore.Collect(it)
+	Ok(t, err)
+	return types(records)
+}
+
+func TestStream(t *testing.T) {
+	filters := []struct {
+		name     string
+		filter   eventstore.Filter
+		expected []string
+	}{
+		{"all", eventstore.Filter{}, []string{"e1", "e2", "e3", "e4"}},
+		{"backward", eventstore.Filter{Direction: eventstore.Backward}, []string{"e4", "e3", "e2", "e1"}},
+		{"from", eventstore.Filter{From: 2}, []string{"e2", "e3", "e4"}},
+		{"backward from", eventstore.Filter{Direction: eventstore.Backward, From: 3}, []string{"e3", "e2", "e1"}},
+		{"stream", eventstore.Filter{StreamID: "stream-1"}, []string{"e1", "e2", "e4"}},
+		{"stream from", eventstore.Filter{StreamID: "stream-1", From: 3}, []string{"e4"}},
+		{"stream backward", eventstore.Filter{StreamID: "stream-1", Direction: eventstore.Backward, From: 3},
+			[]string{"e2", "e1"}},
+		{"types", eventstore.Filter{Types: []string{"e2", "e3"}, BatchSize: 1}, []string{"e2", "e3"}},
+		{"missing stream", eventstore.Filter{StreamID: "missing"}, []string{}},
+	}
+	for _, f := range filters {
+		Equals(t, f.expected, stream(t, aStore(), f.filter))
+		Equals(t, f.expected, stream(t, plainStore{aStore()}, f.filter))
+	}
+}
+
+func TestStream_TimeRange(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := eventstore.NewMemoryStore()
+	for i, name := range []string{"e1", "e2", "e3", "e4"} {
+		s.Append("stream-1", eventstore.AnyVersion, timedEvent{name, now.Add(time.Duration(i) * time.Hour)})
+	}
+	filter := eventstore.Filter{Since: now.Add(time.Hour), Until: now.Add(3 * time.Hour)}
+
+	Equals(t, []string{"e2", "e3"}, stream(t, s, filter))
+}
+
+func TestStream_InvalidFilter(t *testing.T) {
+	_, err := aStore().Stream(context.Background(), eventstore.Filter{BatchSize: -1})
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestStream_AppendedWhileIterating(t *testing.T) {
+	s := aStore()
+	it, _ := s.Stream(context.Background(), eventstore.Filter{BatchSize: 2})
+	defer it.Close()
+	names := make([]string, 0)
+	for it.Next() {
+		if len(names) == 0 {
+			s.Append("stream-3", eventstore.NoStream, testEvent{"e5"})
+		}
+		names = append(names, it.Record().Event.Type())
+	}
+
+	Ok(t, it.Err())
+	Equals(t, []string{"e1", "e2", "e3", "e4", "e5"}, names)
+}
+
+func TestStream_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	it, _ := aStore().Stream(ctx, eventstore.Filter{})
+	defer it.Close()
+
+	Assert(t, it.Next(), "should return the first record")
+	cancel()
+	Assert(t, !it.Next(), "should stop the iteration")
+	Equals(t, context.Canceled, it.Err())
+}
+
+func TestStream_Closed(t *testing.T) {
+	it, _ := aStore().Stream(context.Background(), eventstore.Filter{})
+	it.Close()
+
+	Assert(t, !it.Next(), "should stop the iteration")
+	Ok(t, it.Err())
+}
@@ -0,0 +1,123 @@
+package eventstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+)
+
+// MemoryStore is a thread-safe event store keeping events in memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records []Record
+	streams map[string][]int
+}
+
+// NewMemoryStore will create a new empty in-memory event store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{streams: make(map[string][]int)}
+}
+
+// Append will append the supplied events to the stream, checking that the current version of the stream is equal
+// to expected version. The new version of the stream is returned.
+func (s *MemoryStore) Append(streamID string, expectedVersion int, events ...domain.Event) (int, error) {
+	if err := assert.NotEmpty(streamID, "streamID"); err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stream := s.streams[streamID]
+	if err := checkVersion(streamID, expectedVersion, len(stream)); err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		if err := assert.NotNil(event, "event"); err != nil {
+			return 0, err
+		}
+	}
+	for _, event := range events {
+		s.records = append(s.records, Record{
+			StreamID: streamID,
+			Version:  len(stream) + 1,
+			Position: int64(len(s.records) + 1),
+			Event:    event,
+		})
+		stream = append(stream, len(s.records)-1)
+	}
+	s.streams[streamID] = stream
+	return len(stream), nil
+}
+
+// Load will retrieve the records of the stream, starting from supplied version included.
+func (s *MemoryStore) Load(streamID string, fromVersion int) ([]Record, error) {
+	if err := assert.NotEmpty(streamID, "streamID"); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stream := s.streams[streamID]
+	if fromVersion < 1 {
+		fromVersion = 1
+	}
+	records := make([]Record, 0)
+	for i := fromVersion - 1; i < len(stream); i++ {
+		records = append(records, s.records[stream[i]])
+	}
+	return records, nil
+}
+
+// ReadAll will retrieve all the records of the store, in global order.
+func (s *MemoryStore) ReadAll() ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]Record, len(s.records))
+	copy(records, s.records)
+	return records, nil
+}
+
+// Stream will return an iterator over the records satisfying supplied filter. Forward iterations deliver the records
+// appended while iterating as well, backward iterations start from the last record at the time of the call.
+func (s *MemoryStore) Stream(ctx context.Context, filter Filter) (Iterator, error) {
+	if err := assert.NotNil(ctx, "ctx"); err != nil {
+		return nil, err
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	get := func(i int) Record {
+		if filter.StreamID == "" {
+			return s.records[i]
+		}
+		return s.records[s.streams[filter.StreamID][i]]
+	}
+	count := func() int {
+		if filter.StreamID == "" {
+			return len(s.records)
+		}
+		return len(s.streams[filter.StreamID])
+	}
+	if filter.Direction == Backward {
+		end := count()
+		if filter.From > 0 {
+			end = sort.Search(end, func(i int) bool { return get(i).Position > filter.From })
+		}
+		return newCursor(ctx, filter, 0, func(next int, limit int) ([]Record, int, bool, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			batch, next, done := fetch(get, end, next, limit, filter)
+			return batch, next, done, nil
+		}), nil
+	}
+	start := sort.Search(count(), func(i int) bool { return get(i).Position >= filter.From })
+	return newCursor(ctx, filter, start, func(next int, limit int) ([]Record, int, bool, error) {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		batch, next, done := fetch(get, count(), next, limit, filter)
+		return batch, next, done, nil
+	}), nil
+}
@@ -0,0 +1,117 @@
+package eventstore_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/eventstore"
+	. "github.com/maurofran/kit/testing"
+)
+
+type testEvent struct {
+	name string
+}
+
+func (e testEvent) Type() string {
+	return e.name
+}
+
+func (e testEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e testEvent) Version() int {
+	return 1
+}
+
+func aStore() *eventstore.MemoryStore {
+	s := eventstore.NewMemoryStore()
+	s.Append("stream-1", eventstore.NoStream, testEvent{"e1"}, testEvent{"e2"})
+	s.Append("stream-2", eventstore.NoStream, testEvent{"e3"})
+	s.Append("stream-1", 2, testEvent{"e4"})
+	return s
+}
+
+func TestAppend_NewStream(t *testing.T) {
+	s := eventstore.NewMemoryStore()
+	version, err := s.Append("stream-1", eventstore.NoStream, testEvent{"e1"}, testEvent{"e2"})
+
+	Ok(t, err)
+	Equals(t, 2, version)
+}
+
+func TestAppend_EmptyStreamID(t *testing.T) {
+	_, err := eventstore.NewMemoryStore().Append("", eventstore.NoStream, testEvent{"e1"})
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestAppend_WrongExpectedVersion(t *testing.T) {
+	s := aStore()
+	_, err := s.Append("stream-1", 2, testEvent{"e5"})
+
+	Assert(t, eventstore.IsConcurrencyError(err), "should return a concurrency error")
+	records, _ := s.Load("stream-1", 1)
+	Equals(t, 3, len(records))
+}
+
+func TestAppend_AnyVersion(t *testing.T) {
+	version, err := aStore().Append("stream-1", eventstore.AnyVersion, testEvent{"e5"})
+
+	Ok(t, err)
+	Equals(t, 4, version)
+}
+
+func TestAppend_Concurrent(t *testing.T) {
+	s := eventstore.NewMemoryStore()
+	var wg sync.WaitGroup
+	conflicts := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Append("stream-1", eventstore.NoStream, testEvent{"e1"}); err != nil {
+				conflicts <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(conflicts)
+
+	Equals(t, 9, len(conflicts))
+	for err := range conflicts {
+		Assert(t, eventstore.IsConcurrencyError(err), "should return a concurrency error")
+	}
+}
+
+func TestLoad_MissingStream(t *testing.T) {
+	records, err := aStore().Load("missing", 1)
+
+	Ok(t, err)
+	Equals(t, 0, len(records))
+}
+
+func TestLoad_FromVersion(t *testing.T) {
+	records, err := aStore().Load("stream-1", 2)
+
+	Ok(t, err)
+	Equals(t, 2, len(records))
+	Equals(t, 2, records[0].Version)
+	Equals(t, testEvent{"e2"}, records[0].Event)
+	Equals(t, 3, records[1].Version)
+	Equals(t, int64(4), records[1].Position)
+	Equals(t, testEvent{"e4"}, records[1].Event)
+}
+
+func TestReadAll(t *testing.T) {
+	records, err := aStore().ReadAll()
+
+	Ok(t, err)
+	Equals(t, 4, len(records))
+	Equals(t, "stream-2", records[2].StreamID)
+	Equals(t, []string{"e1", "e2", "e3", "e4"}, []string{
+		records[0].Event.Type(), records[1].Event.Type(), records[2].Event.Type(), records[3].Event.Type(),
+	})
+}
@@ -0,0 +1,283 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/internal/sqlutil"
+)
+
+// Dialect is the set of database specific features used by the SQL event store.
+//
+// The schema of the store is made of three tables:
+//
+//   - es_streams, holding the current version of each stream;
+//   - es_events, holding the events with their global position, stream and version, unique per stream;
+//   - es_position, holding the single row with the last global position assigned.
+//
+// Appends update the es_position row first inside their transaction, locking it, so that positions are assigned in
+// commit order without gaps and the version of the stream is read once the preceding appends are committed.
+type Dialect = sqlutil.Dialect
+
+// SQLite is the dialect of SQLite databases.
+var SQLite = Dialect{
+	Schema: []string{
+		`CREATE TABLE IF NOT EXISTS es_streams (
+			stream_id VARCHAR(255) NOT NULL PRIMARY KEY,
+			version   INTEGER      NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS es_events (
+			position   BIGINT       NOT NULL PRIMARY KEY,
+			stream_id  VARCHAR(255) NOT NULL,
+			version    INTEGER      NOT NULL,
+			event_type VARCHAR(255) NOT NULL,
+			data       BLOB         NOT NULL,
+			UNIQUE (stream_id, version)
+		)`,
+		`CREATE TABLE IF NOT EXISTS es_position (
+			id       INTEGER NOT NULL PRIMARY KEY,
+			position BIGINT  NOT NULL
+		)`,
+		`INSERT INTO es_position (id, position) SELECT 1, 0 WHERE NOT EXISTS (SELECT 1 FROM es_position)`,
+	},
+	Placeholder: sqlutil.QuestionMark,
+}
+
+// PostgreSQL is the dialect of PostgreSQL databases.
+var PostgreSQL = Dialect{
+	Schema: []string{
+		`CREATE TABLE IF NOT EXISTS es_streams (
+			stream_id VARCHAR(255) NOT NULL PRIMARY KEY,
+			version   INTEGER      NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS es_events (
+			position   BIGINT       NOT NULL PRIMARY KEY,
+			stream_id  VARCHAR(255) NOT NULL,
+			version    INTEGER      NOT NULL,
+			event_type VARCHAR(255) NOT NULL,
+			data       BYTEA        NOT NULL,
+			UNIQUE (stream_id, version)
+		)`,
+		`CREATE TABLE IF NOT EXISTS es_position (
+			id       INTEGER NOT NULL PRIMARY KEY,
+			position BIGINT  NOT NULL
+		)`,
+		`INSERT INTO es_position (id, position) SELECT 1, 0 WHERE NOT EXISTS (SELECT 1 FROM es_position)`,
+	},
+	Placeholder: sqlutil.Numbered,
+}
+
+// SQLStore is an event store persisting events in a relational database through database/sql. Optimistic concurrency
+// is enforced by the unique stream and version constraint of the events table.
+type SQLStore struct {
+	db      *sql.DB
+	codec   codec.Codec
+	dialect Dialect
+}
+
+// NewSQLStore will create a new event store persisting events in supplied database with supplied dialect. Events are
+// encoded with supplied codec.
+func NewSQLStore(db *sql.DB, c codec.Codec, dialect Dialect) (*SQLStore, error) {
+	if err := assert.NotNil(db, "db"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(c, "codec"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(dialect.Placeholder, "dialect.Placeholder"); err != nil {
+		return nil, err
+	}
+	return &SQLStore{db: db, codec: c, dialect: dialect}, nil
+}
+
+// CreateSchema will create the tables of the store, if not existing.
+func (s *SQLStore) CreateSchema() error {
+	return s.dialect.CreateSchema(s.db)
+}
+
+// Append will append the supplied events to the stream, checking that the current version of the stream is equal
+// to expected version. The new version of the stream is returned.
+func (s *SQLStore) Append(streamID string, expectedVersion int, events ...domain.Event) (int, error) {
+	if err := assert.NotEmpty(streamID, "streamID"); err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		if err := assert.NotNil(event, "event"); err != nil {
+			return 0, err
+		}
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	version, err := s.append(tx, streamID, expectedVersion, events)
+	if err != nil {
+		tx.Rollback()
+		return 0, s.conflict(streamID, expectedVersion, version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// append will append the events in supplied transaction. The es_position row is locked before reading the version of
+// the stream, so that concurrent appends with any version are serialized instead of failing.
+func (s *SQLStore) append(tx *sql.Tx, streamID string, expectedVersion int, events []domain.Event) (int, error) {
+	if len(events) > 0 {
+		_, err := tx.Exec(s.dialect.Bind("UPDATE es_position SET position = position + ? WHERE id = 1"), len(events))
+		if err != nil {
+			return 0, err
+		}
+	}
+	var current int
+	err := tx.QueryRow(s.dialect.Bind("SELECT version FROM es_streams WHERE stream_id = ?"), streamID).Scan(&current)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if err := checkVersion(streamID, expectedVersion, current); err != nil {
+		return current, err
+	}
+	if len(events) == 0 {
+		return current, nil
+	}
+	var last int64
+	if err := tx.QueryRow("SELECT position FROM es_position WHERE id = 1").Scan(&last); err != nil {
+		return current, err
+	}
+	version := current + len(events)
+	var result sql.Result
+	if current == 0 {
+		result, err = tx.Exec(s.dialect.Bind("INSERT INTO es_streams (stream_id, version) VALUES (?, ?)"), streamID, version)
+	} else {
+		result, err = tx.Exec(s.dialect.Bind("UPDATE es_streams SET version = ? WHERE stream_id = ? AND version = ?"),
+			version, streamID, current)
+	}
+	if err != nil {
+		return current, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows != 1 {
+		return current, concurrencyError{streamID, expectedVersion, current}
+	}
+	insert := s.dialect.Bind("INSERT INTO es_events (position, stream_id, version, event_type, data) " +
+		"VALUES (?, ?, ?, ?, ?)")
+	for i, event := range events {
+		position := last - int64(len(events)) + int64(i) + 1
+		envelope, err := domain.NewEnvelope(strconv.FormatInt(position, 10), streamID, current+i+1, event, nil)
+		if err != nil {
+			return current, err
+		}
+		data, err := s.codec.Marshal(envelope)
+		if err != nil {
+			return current, err
+		}
+		if _, err := tx.Exec(insert, position, streamID, current+i+1, event.Type(), data); err != nil {
+			return current, err
+		}
+	}
+	return version, nil
+}
+
+// conflict will translate the supplied append error in a concurrency error if the stream was modified by a
+// concurrent append, reading back the version of the stream.
+func (s *SQLStore) conflict(streamID string, expectedVersion, current int, err error) error {
+	if IsConcurrencyError(err) || assert.IsArgumentError(err) {
+		return err
+	}
+	var actual int
+	query := s.dialect.Bind("SELECT version FROM es_streams WHERE stream_id = ?")
+	if s.db.QueryRow(query, streamID).Scan(&actual) == nil && actual != current {
+		return concurrencyError{streamID, expectedVersion, actual}
+	}
+	return err
+}
+
+// Load will retrieve the records of the stream, starting from supplied version included.
+func (s *SQLStore) Load(streamID string, fromVersion int) ([]Record, error) {
+	if err := assert.NotEmpty(streamID, "streamID"); err != nil {
+		return nil, err
+	}
+	return s.query(context.Background(), "SELECT position, stream_id, version, data FROM es_events "+
+		"WHERE stream_id = ? AND version >= ? ORDER BY version", streamID, fromVersion)
+}
+
+// ReadAll will retrieve all the records of the store, in global order.
+func (s *SQLStore) ReadAll() ([]Record, error) {
+	return s.query(context.Background(), "SELECT position, stream_id, version, data FROM es_events ORDER BY position")
+}
+
+// Stream will return an iterator over the records satisfying supplied filter. The records are queried in pages of
+// the filter batch size, the stream and position criteria being applied by the database.
+func (s *SQLStore) Stream(ctx context.Context, filter Filter) (Iterator, error) {
+	if err := assert.NotNil(ctx, "ctx"); err != nil {
+		return nil, err
+	}
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+	query := "SELECT position, stream_id, version, data FROM es_events WHERE position >= ?"
+	order := "ASC"
+	next := filter.From
+	if filter.Direction == Backward {
+		query = "SELECT position, stream_id, version, data FROM es_events WHERE position <= ?"
+		order = "DESC"
+		if next == 0 {
+			if err := s.db.QueryRowContext(ctx, "SELECT position FROM es_position WHERE id = 1").Scan(&next); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if filter.StreamID != "" {
+		query += " AND stream_id = ?"
+	}
+	query += fmt.Sprintf(" ORDER BY position %s LIMIT ?", order)
+	return newCursor(ctx, filter, int(next), func(next int, limit int) ([]Record, int, bool, error) {
+		args := []interface{}{next}
+		if filter.StreamID != "" {
+			args = append(args, filter.StreamID)
+		}
+		page, err := s.query(ctx, query, append(args, limit)...)
+		if err != nil {
+			return nil, next, true, err
+		}
+		batch := make([]Record, 0, len(page))
+		for _, record := range page {
+			next = int(record.Position) + 1
+			if filter.Direction == Backward {
+				next = int(record.Position) - 1
+			}
+			if filter.Matches(record) {
+				batch = append(batch, record)
+			}
+		}
+		return batch, next, len(page) < limit, nil
+	}), nil
+}
+
+func (s *SQLStore) query(ctx context.Context, query string, args ...interface{}) ([]Record, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.Bind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	records := make([]Record, 0)
+	for rows.Next() {
+		var record Record
+		var data []byte
+		if err := rows.Scan(&record.Position, &record.StreamID, &record.Version, &data); err != nil {
+			return nil, err
+		}
+		envelope, err := s.codec.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		record.Event = envelope.Event()
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
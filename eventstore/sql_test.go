@@ -0,0 +1,53 @@
+package eventstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/eventstore"
+	. "github.com/maurofran/kit/testing"
+)
+
+func TestNewSQLStore_NilDB(t *testing.T) {
+	c, _ := codec.NewJSONCodec(codec.NewRegistry())
+	_, err := eventstore.NewSQLStore(nil, c, eventstore.SQLite)
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestSQLStore_AppendContended(t *testing.T) {
+	db, fake := openFakeDB(t)
+	c, _ := codec.NewJSONCodec(codec.NewRegistry())
+	s, _ := eventstore.NewSQLStore(db, c, eventstore.SQLite)
+	_, err := s.Append("stream-1", eventstore.NoStream, fileEvent{"e1"})
+	Ok(t, err)
+	tx, _ := db.Begin()
+	_, err = tx.Exec("UPDATE es_position SET position = position + ? WHERE id = 1", 1)
+	Ok(t, err)
+	type result struct {
+		version int
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		version, err := s.Append("stream-1", eventstore.AnyVersion, fileEvent{"e3"})
+		done <- result{version, err}
+	}()
+	for fake.waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	_, err = tx.Exec("UPDATE es_streams SET version = ? WHERE stream_id = ? AND version = ?", 2, "stream-1", 1)
+	Ok(t, err)
+	_, err = tx.Exec("INSERT INTO es_events (position, stream_id, version, event_type, data) VALUES (?, ?, ?, ?, ?)",
+		2, "stream-1", 2, "e2", []byte("{}"))
+	Ok(t, err)
+	Ok(t, tx.Commit())
+
+	appended := <-done
+	Ok(t, appended.err)
+	Equals(t, 3, appended.version)
+	_, err = s.Append("stream-1", 2, fileEvent{"e4"})
+	Assert(t, eventstore.IsConcurrencyError(err), "should return a concurrency error, got %v", err)
+}
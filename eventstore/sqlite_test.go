@@ -0,0 +1,99 @@
+//go:build sqlite
+// +build sqlite
+
+// The SQL event store tests run against SQLite when the sqlite build tag is supplied, after adding the
+// modernc.org/sqlite driver, which is not a dependency of the module:
+//
+//	go get modernc.org/sqlite
+//	go test -tags sqlite ./eventstore
+package eventstore_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/eventstore"
+	. "github.com/maurofran/kit/testing"
+	_ "modernc.org/sqlite"
+)
+
+func aSQLStore(t *testing.T) *eventstore.SQLStore {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "events.db"))
+	Ok(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	r := codec.NewRegistry()
+	r.Register(fileEvent{"e1"}, fileEvent{"e2"}, fileEvent{"e3"}, fileEvent{"e4"}, fileEvent{"e5"})
+	c, _ := codec.NewJSONCodec(r)
+	s, err := eventstore.NewSQLStore(db, c, eventstore.SQLite)
+	Ok(t, err)
+	Ok(t, s.CreateSchema())
+	Ok(t, s.CreateSchema())
+	s.Append("stream-1", eventstore.NoStream, fileEvent{"e1"}, fileEvent{"e2"})
+	s.Append("stream-2", eventstore.NoStream, fileEvent{"e3"})
+	s.Append("stream-1", 2, fileEvent{"e4"})
+	return s
+}
+
+func TestSQLStore_Append(t *testing.T) {
+	s := aSQLStore(t)
+	version, err := s.Append("stream-1", 3, fileEvent{"e5"})
+
+	Ok(t, err)
+	Equals(t, 4, version)
+	_, err = s.Append("stream-1", 3, fileEvent{"e5"})
+	Assert(t, eventstore.IsConcurrencyError(err), "should return a concurrency error")
+	_, err = s.Append("stream-2", eventstore.NoStream, fileEvent{"e5"})
+	Assert(t, eventstore.IsConcurrencyError(err), "should return a concurrency error")
+}
+
+func TestSQLStore_AppendConcurrent(t *testing.T) {
+	s := aSQLStore(t)
+	var wg sync.WaitGroup
+	conflicts := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Append("stream-3", eventstore.NoStream, fileEvent{"e5"}); err != nil {
+				conflicts <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(conflicts)
+
+	Equals(t, 4, len(conflicts))
+	for err := range conflicts {
+		Assert(t, eventstore.IsConcurrencyError(err), "should return a concurrency error, got %v", err)
+	}
+}
+
+func TestSQLStore_Load(t *testing.T) {
+	records, err := aSQLStore(t).Load("stream-1", 2)
+
+	Ok(t, err)
+	Equals(t, []eventstore.Record{
+		{StreamID: "stream-1", Version: 2, Position: 2, Event: fileEvent{"e2"}},
+		{StreamID: "stream-1", Version: 3, Position: 4, Event: fileEvent{"e4"}},
+	}, records)
+}
+
+func TestSQLStore_ReadAll(t *testing.T) {
+	records, err := aSQLStore(t).ReadAll()
+
+	Ok(t, err)
+	Equals(t, []string{"e1", "e2", "e3", "e4"}, types(records))
+}
+
+func TestSQLStore_Stream(t *testing.T) {
+	s := aSQLStore(t)
+
+	Equals(t, []string{"e4", "e2", "e1"}, stream(t, s, eventstore.Filter{
+		StreamID: "stream-1", Direction: eventstore.Backward, BatchSize: 1,
+	}))
+	Equals(t, []string{"e2", "e3"}, stream(t, s, eventstore.Filter{From: 2, Types: []string{"e2", "e3"}}))
+}
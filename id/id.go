@@ -0,0 +1,83 @@
+package id
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Generator is the interface implemented by identifier generators. It is satisfied by domain.IDGenerator.
+type Generator interface {
+	// NewID will return a new unique identifier in its canonical string form.
+	NewID() string
+}
+
+// GeneratorFunc is an adapter allowing to use ordinary functions as identifier generators.
+type GeneratorFunc func() string
+
+// NewID will invoke the receiver function.
+func (f GeneratorFunc) NewID() string {
+	return f()
+}
+
+// entropy is a thread-safe source of random bytes, defaulting to crypto/rand. Once the supplied source fails, for
+// instance because it is exhausted, crypto/rand is read instead.
+type entropy struct {
+	mu     sync.Mutex
+	reader io.Reader
+}
+
+func newEntropy(reader io.Reader) *entropy {
+	if reader == nil {
+		reader = rand.Reader
+	}
+	return &entropy{reader: reader}
+}
+
+func (e *entropy) read(b []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, err := io.ReadFull(e.reader, b); err == nil {
+		return
+	}
+	e.reader = rand.Reader
+	if _, err := io.ReadFull(e.reader, b); err != nil {
+		// the system random source failing is unrecoverable, as crypto/rand.Read does since Go 1.24
+		panic(fmt.Sprintf("id: unable to read random bytes: %v", err))
+	}
+}
+
+func invalid(kind, value string) error {
+	return assert.Condition(false, fmt.Sprintf("%q is not a valid %s", value, kind))
+}
+
+// scan will parse the supplied database value with supplied text unmarshaller, accepting strings and bytes in text
+// form, or raw bytes of supplied size.
+func scan(src interface{}, size int, raw []byte, unmarshal func([]byte) error) error {
+	switch v := src.(type) {
+	case string:
+		return unmarshal([]byte(v))
+	case []byte:
+		if len(v) == size {
+			copy(raw, v)
+			return nil
+		}
+		return unmarshal(v)
+	default:
+		return assert.Condition(false, fmt.Sprintf("unable to scan %T as identifier", src))
+	}
+}
+
+// increment will add one to the supplied big endian number, returning false on overflow.
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
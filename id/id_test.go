@@ -0,0 +1,204 @@
+package id_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/id"
+	. "github.com/maurofran/kit/testing"
+)
+
+var start = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func uuidv4(t *testing.T, entropy io.Reader) *id.UUIDv4Generator {
+	g, err := id.NewUUIDv4Generator(entropy)
+	Ok(t, err)
+	return g
+}
+
+func uuidv7(t *testing.T, clk clock.Clock, entropy io.Reader) *id.UUIDv7Generator {
+	g, err := id.NewUUIDv7Generator(clk, entropy)
+	Ok(t, err)
+	return g
+}
+
+func ulid(t *testing.T, clk clock.Clock, entropy io.Reader) *id.ULIDGenerator {
+	g, err := id.NewULIDGenerator(clk, entropy)
+	Ok(t, err)
+	return g
+}
+
+func ksuid(t *testing.T, clk clock.Clock, entropy io.Reader) *id.KSUIDGenerator {
+	g, err := id.NewKSUIDGenerator(clk, entropy)
+	Ok(t, err)
+	return g
+}
+
+func generators(t *testing.T, clk clock.Clock) map[string]id.Generator {
+	return map[string]id.Generator{
+		"uuidv7": uuidv7(t, clk, nil),
+		"ulid":   ulid(t, clk, nil),
+		"ksuid":  ksuid(t, clk, nil),
+	}
+}
+
+func TestUUIDv4(t *testing.T) {
+	g := uuidv4(t, nil)
+	u := g.Next()
+	parsed, err := id.ParseUUID(u.String())
+
+	Ok(t, err)
+	Equals(t, u, parsed)
+	Equals(t, 4, u.Version())
+	Equals(t, byte(0x80), u[8]&0xc0)
+	Assert(t, g.NewID() != g.NewID(), "ids should be unique")
+}
+
+func TestUUIDv4_DeterministicEntropy(t *testing.T) {
+	u := uuidv4(t, bytes.NewReader(make([]byte, 16))).Next()
+
+	Equals(t, "00000000-0000-4000-8000-000000000000", u.String())
+}
+
+func TestUUIDv4_FailingEntropy(t *testing.T) {
+	g := uuidv4(t, bytes.NewReader(make([]byte, 16)))
+	first := g.Next()
+	second := g.Next()
+
+	Assert(t, first != second, "ids should be unique")
+	Assert(t, uuidv4(t, iotest.ErrReader(errors.New("broken"))).NewID() != "", "should fall back to crypto/rand")
+}
+
+func TestNew_NilClock(t *testing.T) {
+	_, err := id.NewUUIDv7Generator(nil, nil)
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+	_, err = id.NewULIDGenerator(nil, nil)
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+	_, err = id.NewKSUIDGenerator(nil, nil)
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestUUIDv7(t *testing.T) {
+	u := uuidv7(t, clock.NewFake(start), nil).Next()
+
+	Equals(t, 7, u.Version())
+	Equals(t, "016f6435-cc88", u.String()[:13])
+}
+
+func TestMonotonicWithinSameInstant(t *testing.T) {
+	for name, g := range generators(t, clock.NewFake(start)) {
+		ids := make([]string, 1000)
+		for i := range ids {
+			ids[i] = g.NewID()
+		}
+
+		Assert(t, sort.StringsAreSorted(ids), "%s: ids are not monotonic", name)
+		for i := 1; i < len(ids); i++ {
+			Assert(t, ids[i-1] != ids[i], "%s: duplicated id %s", name, ids[i])
+		}
+	}
+}
+
+func TestSortedByTime(t *testing.T) {
+	clk := clock.NewFake(start)
+	for name, g := range generators(t, clk) {
+		first := g.NewID()
+		clk.Advance(time.Second)
+		second := g.NewID()
+
+		Assert(t, first < second, "%s: %s should sort before %s", name, first, second)
+	}
+}
+
+func TestULID(t *testing.T) {
+	u := ulid(t, clock.NewFake(start), nil).Next()
+	parsed, err := id.ParseULID(u.String())
+
+	Ok(t, err)
+	Equals(t, u, parsed)
+	Equals(t, start.UnixNano()/1e6, u.Time())
+	Equals(t, 26, len(u.String()))
+}
+
+func TestKSUID(t *testing.T) {
+	k := ksuid(t, clock.NewFake(start), nil).Next()
+	parsed, err := id.ParseKSUID(k.String())
+
+	Ok(t, err)
+	Equals(t, k, parsed)
+	Assert(t, start.Equal(k.Time()), "unexpected time %v", k.Time())
+	Equals(t, 27, len(k.String()))
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := id.ParseUUID("not-a-uuid")
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+	_, err = id.ParseUUID("0000000g-0000-4000-8000-000000000000")
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+	_, err = id.ParseULID("8ZZZZZZZZZZZZZZZZZZZZZZZZZ")
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+	_, err = id.ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAU")
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+	_, err = id.ParseKSUID("zzzzzzzzzzzzzzzzzzzzzzzzzzz")
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
+
+func TestParseULID_CaseInsensitive(t *testing.T) {
+	upper, err := id.ParseULID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	Ok(t, err)
+	lower, err := id.ParseULID("01arz3ndektsv4rrffq69g5fav")
+
+	Ok(t, err)
+	Equals(t, upper, lower)
+}
+
+type identified struct {
+	UUID  id.UUID
+	ULID  id.ULID
+	KSUID id.KSUID
+}
+
+func TestJSON(t *testing.T) {
+	clk := clock.NewFake(start)
+	v := identified{
+		uuidv4(t, nil).Next(),
+		ulid(t, clk, nil).Next(),
+		ksuid(t, clk, nil).Next(),
+	}
+	data, err := json.Marshal(v)
+	Ok(t, err)
+	var decoded identified
+	err = json.Unmarshal(data, &decoded)
+
+	Ok(t, err)
+	Equals(t, v, decoded)
+	Assert(t, bytes.Contains(data, []byte(`"`+v.UUID.String()+`"`)), "unexpected JSON %s", data)
+}
+
+func TestSQL(t *testing.T) {
+	u := uuidv4(t, nil).Next()
+	value, err := u.Value()
+	Ok(t, err)
+	var fromText, fromRaw id.UUID
+
+	Ok(t, fromText.Scan(value))
+	Ok(t, fromRaw.Scan(u[:]))
+	Equals(t, u, fromText)
+	Equals(t, u, fromRaw)
+	Assert(t, assert.IsArgumentError(fromText.Scan(42)), "should return an argument error")
+
+	k := ksuid(t, clock.NewFake(start), nil).Next()
+	value, _ = k.Value()
+	var scanned id.KSUID
+
+	Ok(t, scanned.Scan([]byte(value.(string))))
+	Equals(t, k, scanned)
+}
@@ -0,0 +1,135 @@
+package id
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+)
+
+const (
+	base62      = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	ksuidEpoch  = 1400000000
+	ksuidLength = 27
+)
+
+var maxKSUID = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+
+// KSUID is a K-sortable unique identifier: a 32 bits timestamp in seconds since 2014-05-13T16:53:20Z followed by
+// 128 random bits, encoded in 27 base62 characters.
+type KSUID [20]byte
+
+// ParseKSUID will parse the supplied string in canonical form, returning an argument error if it's not valid.
+func ParseKSUID(s string) (KSUID, error) {
+	var k KSUID
+	if len(s) != ksuidLength {
+		return k, invalid("KSUID", s)
+	}
+	n := new(big.Int)
+	base := big.NewInt(62)
+	for _, c := range s {
+		digit := strings.IndexRune(base62, c)
+		if digit < 0 {
+			return KSUID{}, invalid("KSUID", s)
+		}
+		n.Mul(n, base).Add(n, big.NewInt(int64(digit)))
+	}
+	if n.Cmp(maxKSUID) > 0 {
+		return KSUID{}, invalid("KSUID", s)
+	}
+	n.FillBytes(k[:])
+	return k, nil
+}
+
+// String will return the canonical form of the KSUID.
+func (k KSUID) String() string {
+	n := new(big.Int).SetBytes(k[:])
+	base := big.NewInt(62)
+	digit := new(big.Int)
+	var buf [ksuidLength]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		buf[i] = base62[digit.Int64()]
+	}
+	return string(buf[:])
+}
+
+// Time will return the timestamp of the KSUID.
+func (k KSUID) Time() time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(k[:4]))+ksuidEpoch, 0)
+}
+
+// IsZero will check if the KSUID is zero.
+func (k KSUID) IsZero() bool {
+	return k == KSUID{}
+}
+
+// MarshalText will return the canonical form of the KSUID, used by JSON encoding as well.
+func (k KSUID) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText will parse the KSUID from its canonical form, used by JSON decoding as well.
+func (k *KSUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseKSUID(string(text))
+	if err != nil {
+		return err
+	}
+	*k = parsed
+	return nil
+}
+
+// Value will return the canonical form of the KSUID to store it in a database.
+func (k KSUID) Value() (driver.Value, error) {
+	return k.String(), nil
+}
+
+// Scan will read the KSUID from a database value, either in canonical form or as 20 raw bytes.
+func (k *KSUID) Scan(src interface{}) error {
+	return scan(src, len(k), k[:], k.UnmarshalText)
+}
+
+// KSUIDGenerator is the generator of KSUIDs. KSUIDs generated within the same second are monotonic: the random part
+// of the previous one is incremented.
+type KSUIDGenerator struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	entropy *entropy
+	last    KSUID
+}
+
+// NewKSUIDGenerator will create a new generator of KSUIDs timestamped with supplied clock and reading supplied
+// entropy source, crypto/rand if nil or once the source fails.
+func NewKSUIDGenerator(clk clock.Clock, entropy io.Reader) (*KSUIDGenerator, error) {
+	if err := assert.NotNil(clk, "clk"); err != nil {
+		return nil, err
+	}
+	return &KSUIDGenerator{clock: clk, entropy: newEntropy(entropy)}, nil
+}
+
+// Next will generate a new KSUID, greater than the previous one generated.
+func (g *KSUIDGenerator) Next() KSUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	seconds := uint32(g.clock.Now().Unix() - ksuidEpoch)
+	if g.last.IsZero() || seconds > binary.BigEndian.Uint32(g.last[:4]) {
+		var k KSUID
+		binary.BigEndian.PutUint32(k[:4], seconds)
+		g.entropy.read(k[4:])
+		g.last = k
+	} else if !increment(g.last[4:]) {
+		increment(g.last[:4])
+	}
+	return g.last
+}
+
+// NewID will generate a new KSUID in canonical form.
+func (g *KSUIDGenerator) NewID() string {
+	return g.Next().String()
+}
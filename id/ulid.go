@@ -0,0 +1,128 @@
+package id
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+)
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ULID is a universally unique lexicographically sortable identifier: a 48 bits millisecond timestamp followed by
+// 80 random bits, encoded in 26 Crockford base32 characters.
+type ULID [16]byte
+
+// ParseULID will parse the supplied string in canonical form, case insensitively, returning an argument error if
+// it's not valid.
+func ParseULID(s string) (ULID, error) {
+	var u ULID
+	if len(s) != 26 || s[0] > '7' {
+		return u, invalid("ULID", s)
+	}
+	n := new(big.Int)
+	for _, c := range strings.ToUpper(s) {
+		digit := strings.IndexRune(crockford, c)
+		if digit < 0 {
+			return ULID{}, invalid("ULID", s)
+		}
+		n.Lsh(n, 5).Or(n, big.NewInt(int64(digit)))
+	}
+	n.FillBytes(u[:])
+	return u, nil
+}
+
+// String will return the canonical form of the ULID.
+func (u ULID) String() string {
+	n := new(big.Int).SetBytes(u[:])
+	mask := big.NewInt(31)
+	var buf [26]byte
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = crockford[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 5)
+	}
+	return string(buf[:])
+}
+
+// Time will return the millisecond timestamp of the ULID, in milliseconds since the Unix epoch.
+func (u ULID) Time() int64 {
+	var ms [8]byte
+	copy(ms[2:], u[:6])
+	return int64(binary.BigEndian.Uint64(ms[:]))
+}
+
+// IsZero will check if the ULID is zero.
+func (u ULID) IsZero() bool {
+	return u == ULID{}
+}
+
+// MarshalText will return the canonical form of the ULID, used by JSON encoding as well.
+func (u ULID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText will parse the ULID from its canonical form, used by JSON decoding as well.
+func (u *ULID) UnmarshalText(text []byte) error {
+	parsed, err := ParseULID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// Value will return the canonical form of the ULID to store it in a database.
+func (u ULID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// Scan will read the ULID from a database value, either in canonical form or as 16 raw bytes.
+func (u *ULID) Scan(src interface{}) error {
+	return scan(src, len(u), u[:], u.UnmarshalText)
+}
+
+// ULIDGenerator is the generator of ULIDs. ULIDs generated within the same millisecond are monotonic: the random
+// part of the previous one is incremented.
+type ULIDGenerator struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	entropy *entropy
+	last    ULID
+}
+
+// NewULIDGenerator will create a new generator of ULIDs timestamped with supplied clock and reading supplied entropy
+// source, crypto/rand if nil or once the source fails.
+func NewULIDGenerator(clk clock.Clock, entropy io.Reader) (*ULIDGenerator, error) {
+	if err := assert.NotNil(clk, "clk"); err != nil {
+		return nil, err
+	}
+	return &ULIDGenerator{clock: clk, entropy: newEntropy(entropy)}, nil
+}
+
+// Next will generate a new ULID, greater than the previous one generated.
+func (g *ULIDGenerator) Next() ULID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ms := g.clock.Now().UnixNano() / 1e6
+	if ms > g.last.Time() {
+		var u ULID
+		var t [8]byte
+		binary.BigEndian.PutUint64(t[:], uint64(ms))
+		copy(u[:6], t[2:])
+		g.entropy.read(u[6:])
+		g.last = u
+	} else if !increment(g.last[6:]) {
+		increment(g.last[:6])
+	}
+	return g.last
+}
+
+// NewID will generate a new ULID in canonical form.
+func (g *ULIDGenerator) NewID() string {
+	return g.Next().String()
+}
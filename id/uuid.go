@@ -0,0 +1,161 @@
+package id
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+)
+
+// UUID is a RFC 9562 universally unique identifier.
+type UUID [16]byte
+
+// Nil is the zero UUID.
+var Nil UUID
+
+// ParseUUID will parse the supplied string in the canonical 8-4-4-4-12 hexadecimal form, returning an argument error
+// if it's not valid.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return Nil, invalid("UUID", s)
+	}
+	src := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(src)); err != nil {
+		return Nil, invalid("UUID", s)
+	}
+	return u, nil
+}
+
+// String will return the canonical form of the UUID.
+func (u UUID) String() string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
+
+// Version will return the version of the UUID.
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// IsZero will check if the UUID is the nil UUID.
+func (u UUID) IsZero() bool {
+	return u == Nil
+}
+
+// MarshalText will return the canonical form of the UUID, used by JSON encoding as well.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText will parse the UUID from its canonical form, used by JSON decoding as well.
+func (u *UUID) UnmarshalText(text []byte) error {
+	parsed, err := ParseUUID(string(text))
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// Value will return the canonical form of the UUID to store it in a database.
+func (u UUID) Value() (driver.Value, error) {
+	return u.String(), nil
+}
+
+// Scan will read the UUID from a database value, either in canonical form or as 16 raw bytes.
+func (u *UUID) Scan(src interface{}) error {
+	return scan(src, len(u), u[:], u.UnmarshalText)
+}
+
+func (u *UUID) setVersion(version byte) {
+	u[6] = u[6]&0x0f | version<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+// UUIDv4Generator is the generator of random UUIDs.
+type UUIDv4Generator struct {
+	entropy *entropy
+}
+
+// NewUUIDv4Generator will create a new generator of random UUIDs reading supplied entropy source, crypto/rand if nil
+// or once the source fails.
+func NewUUIDv4Generator(entropy io.Reader) (*UUIDv4Generator, error) {
+	return &UUIDv4Generator{newEntropy(entropy)}, nil
+}
+
+// Next will generate a new random UUID.
+func (g *UUIDv4Generator) Next() UUID {
+	var u UUID
+	g.entropy.read(u[:])
+	u.setVersion(4)
+	return u
+}
+
+// NewID will generate a new random UUID in canonical form.
+func (g *UUIDv4Generator) NewID() string {
+	return g.Next().String()
+}
+
+// UUIDv7Generator is the generator of time-ordered UUIDs. UUIDs generated within the same millisecond are monotonic:
+// the 12 bits following the timestamp are used as a counter, seeded randomly at each new millisecond.
+type UUIDv7Generator struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	entropy *entropy
+	lastMs  int64
+	counter uint16
+}
+
+// NewUUIDv7Generator will create a new generator of time-ordered UUIDs timestamped with supplied clock and reading
+// supplied entropy source, crypto/rand if nil or once the source fails.
+func NewUUIDv7Generator(clk clock.Clock, entropy io.Reader) (*UUIDv7Generator, error) {
+	if err := assert.NotNil(clk, "clk"); err != nil {
+		return nil, err
+	}
+	return &UUIDv7Generator{clock: clk, entropy: newEntropy(entropy)}, nil
+}
+
+// Next will generate a new time-ordered UUID, greater than the previous one generated.
+func (g *UUIDv7Generator) Next() UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var u UUID
+	g.entropy.read(u[8:])
+	ms := g.clock.Now().UnixNano() / 1e6
+	if ms > g.lastMs {
+		var seed [2]byte
+		g.entropy.read(seed[:])
+		g.lastMs = ms
+		g.counter = binary.BigEndian.Uint16(seed[:]) & 0x07ff
+	} else {
+		g.counter++
+		if g.counter > 0x0fff {
+			g.lastMs++
+			g.counter = 0
+		}
+	}
+	binary.BigEndian.PutUint16(u[4:6], uint16(g.lastMs))
+	binary.BigEndian.PutUint32(u[0:4], uint32(g.lastMs>>16))
+	binary.BigEndian.PutUint16(u[6:8], g.counter)
+	u.setVersion(7)
+	return u
+}
+
+// NewID will generate a new time-ordered UUID in canonical form.
+func (g *UUIDv7Generator) NewID() string {
+	return g.Next().String()
+}
@@ -0,0 +1,54 @@
+package sqlutil
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+// Dialect is the set of database specific features used by the SQL implementations of the kit.
+//
+// Queries are written with question mark placeholders, replaced by the ones of the dialect when bound. Constraint
+// violations are reported differently by each driver, so the implementations never inspect them: a statement failed
+// because of a concurrent change is recognized by reading back the rows it conflicted with.
+type Dialect struct {
+	// Schema is the list of statements creating the tables, if not existing.
+	Schema []string
+	// Placeholder returns the bind parameter placeholder for supplied index, starting from 1.
+	Placeholder func(int) string
+}
+
+// QuestionMark is the placeholder of SQLite databases, a question mark whatever the index.
+func QuestionMark(int) string {
+	return "?"
+}
+
+// Numbered is the placeholder of PostgreSQL databases, a dollar sign followed by the index.
+func Numbered(i int) string {
+	return "$" + strconv.Itoa(i)
+}
+
+// CreateSchema will execute the schema statements of the dialect on supplied database.
+func (d Dialect) CreateSchema(db *sql.DB) error {
+	for _, statement := range d.Schema {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bind will replace the question mark placeholders of supplied query with the ones of the dialect.
+func (d Dialect) Bind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
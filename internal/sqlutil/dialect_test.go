@@ -0,0 +1,20 @@
+package sqlutil_test
+
+import (
+	"testing"
+
+	"github.com/maurofran/kit/internal/sqlutil"
+	. "github.com/maurofran/kit/testing"
+)
+
+func TestBind_QuestionMark(t *testing.T) {
+	d := sqlutil.Dialect{Placeholder: sqlutil.QuestionMark}
+
+	Equals(t, "SELECT a FROM t WHERE b = ? AND c = ?", d.Bind("SELECT a FROM t WHERE b = ? AND c = ?"))
+}
+
+func TestBind_Numbered(t *testing.T) {
+	d := sqlutil.Dialect{Placeholder: sqlutil.Numbered}
+
+	Equals(t, "SELECT a FROM t WHERE b = $1 AND c = $2", d.Bind("SELECT a FROM t WHERE b = ? AND c = ?"))
+}
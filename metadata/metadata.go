@@ -1,5 +1,7 @@
 package metadata
 
+import "context"
+
 // Supplier is the interface for supplying a value
 type Supplier func() interface{}
 
@@ -29,6 +31,15 @@ func (c *Container) Keys() []string {
 	return keys
 }
 
+// Entries will retrieve a copy of all the entries in container.
+func (c *Container) Entries() map[string]interface{} {
+	entries := make(map[string]interface{}, len(c.data))
+	for key, value := range c.data {
+		entries[key] = value
+	}
+	return entries
+}
+
 // Empty will check if container is empty
 func (c *Container) Empty() bool {
 	return len(c.data) == 0
@@ -118,3 +129,34 @@ func With(key string, value interface{}) *Container {
 	c.data[key] = value
 	return c
 }
+
+// Carrier is the interface implemented by messages carrying metadata, as commands and queries.
+type Carrier interface {
+	// Metadata will return the metadata of the message.
+	Metadata() *Container
+}
+
+type contextKey struct{}
+
+// NewContext will return a copy of supplied context carrying the supplied metadata container.
+func NewContext(ctx context.Context, c *Container) context.Context {
+	return context.WithValue(ctx, contextKey{}, c)
+}
+
+// FromContext will retrieve the metadata container carried by supplied context, or an empty one if none.
+func FromContext(ctx context.Context) *Container {
+	if c, ok := ctx.Value(contextKey{}).(*Container); ok && c != nil {
+		return c
+	}
+	return Empty()
+}
+
+// Propagate will return a copy of supplied context whose metadata include the ones of supplied message, if it is a
+// carrier. Context metadata take precedence.
+func Propagate(ctx context.Context, message interface{}) context.Context {
+	carrier, ok := message.(Carrier)
+	if !ok || carrier.Metadata() == nil {
+		return ctx
+	}
+	return NewContext(ctx, carrier.Metadata().MergedWith(FromContext(ctx).Entries()))
+}
@@ -1,6 +1,7 @@
 package metadata_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/maurofran/kit/metadata"
@@ -84,6 +85,21 @@ func TestKeys_TwoKeys(t *testing.T) {
 	Equals(t, 2, len(keys))
 }
 
+func TestEntries_OnEmpty(t *testing.T) {
+	entries := anEmptyContainer().Entries()
+
+	Equals(t, map[string]interface{}{}, entries)
+}
+
+func TestEntries_ReturnsCopy(t *testing.T) {
+	c := aContainer()
+	entries := c.Entries()
+	entries["key3"] = "value3"
+
+	Equals(t, map[string]interface{}{"key1": "value1", "key2": 2, "key3": "value3"}, entries)
+	Equals(t, 2, len(c.Keys()))
+}
+
 func TestAnd(t *testing.T) {
 	c := aContainer()
 	m := c.And("aKey", "aValue")
@@ -229,3 +245,34 @@ func TestWithoutKeys_ShouldRemoveKeys(t *testing.T) {
 	Equals(t, true, ok)
 	Equals(t, 4, value)
 }
+
+func TestFromContext_Missing(t *testing.T) {
+	c := metadata.FromContext(context.Background())
+
+	Assert(t, c != nil, "Metadata container must not be nil")
+	Equals(t, true, c.Empty())
+}
+
+func TestNewContext(t *testing.T) {
+	c := aContainer()
+	ctx := metadata.NewContext(context.Background(), c)
+
+	Assert(t, metadata.FromContext(ctx) == c, "should return the same container")
+}
+
+type carrier struct {
+	md *metadata.Container
+}
+
+func (c carrier) Metadata() *metadata.Container {
+	return c.md
+}
+
+func TestPropagate(t *testing.T) {
+	ctx := metadata.NewContext(context.Background(), metadata.With("key1", "context"))
+	md := metadata.FromContext(metadata.Propagate(ctx, carrier{aContainer()}))
+
+	Equals(t, map[string]interface{}{"key1": "context", "key2": 2}, md.Entries())
+	Assert(t, metadata.Propagate(ctx, "not a carrier") == ctx, "should return the same context")
+	Assert(t, metadata.Propagate(ctx, carrier{}) == ctx, "should return the same context")
+}
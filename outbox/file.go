@@ -0,0 +1,317 @@
+package outbox
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/domain"
+)
+
+const (
+	opSequence  = "sequence"
+	opAdd       = "add"
+	opPublished = "published"
+	opFailed    = "failed"
+)
+
+// journalRecord is a line of the journal of a file store.
+type journalRecord struct {
+	Op        string    `json:"op"`
+	ID        int64     `json:"id"`
+	Data      []byte    `json:"data,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// FileStore is a thread-safe outbox store persisting entries to disk without a database. Every change is appended to
+// a journal file, flushed to the disk before returning, and the pending entries are rebuilt replaying the journal when
+// the store is opened. The journal is rewritten with the pending entries only each time the store is opened.
+//
+// A file store takes no part in the transaction persisting the aggregates, so an event is still lost if the process
+// crashes between committing the aggregate and adding its events: use the SQL store to add them atomically.
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	codec   codec.Codec
+	file    *os.File
+	size    int64
+	entries []Entry
+	nextID  int64
+}
+
+// NewFileStore will open the outbox store journaled in the file with supplied path, creating it if not existing.
+// Events are encoded with supplied codec.
+func NewFileStore(path string, c codec.Codec) (*FileStore, error) {
+	if err := assert.NotEmpty(path, "path"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(c, "codec"); err != nil {
+		return nil, err
+	}
+	s := &FileStore{path: path, codec: c}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+	if err := s.rewrite(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add will append the supplied events to the outbox.
+func (s *FileStore) Add(events ...domain.Event) error {
+	for _, event := range events {
+		if err := assert.NotNil(event, "event"); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.open(); err != nil {
+		return err
+	}
+	now := time.Now()
+	records := make([]journalRecord, 0, len(events))
+	for i, event := range events {
+		id := s.nextID + int64(i) + 1
+		data, err := s.encode(id, event)
+		if err != nil {
+			return err
+		}
+		records = append(records, journalRecord{Op: opAdd, ID: id, Data: data, CreatedAt: now})
+	}
+	if err := s.write(records...); err != nil {
+		return err
+	}
+	for i, event := range events {
+		s.entries = append(s.entries, Entry{ID: records[i].ID, Event: event, CreatedAt: now})
+	}
+	s.nextID += int64(len(events))
+	return nil
+}
+
+// Pending will retrieve, in insertion order, up to limit unpublished entries that failed less than maxAttempts times.
+func (s *FileStore) Pending(limit, maxAttempts int) ([]Entry, error) {
+	if err := assert.IntMin(limit, 1, "limit"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, limit)
+	for _, entry := range s.entries {
+		if len(entries) == limit {
+			break
+		}
+		if entry.Attempts < maxAttempts {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// MarkPublished will mark the entry with supplied id as published, removing it from the store.
+func (s *FileStore) MarkPublished(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.open(); err != nil {
+		return err
+	}
+	i, err := s.indexOf(id)
+	if err != nil {
+		return err
+	}
+	if err := s.write(journalRecord{Op: opPublished, ID: id}); err != nil {
+		return err
+	}
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	return nil
+}
+
+// MarkFailed will record a failed publishing attempt for the entry with supplied id.
+func (s *FileStore) MarkFailed(id int64, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.open(); err != nil {
+		return err
+	}
+	i, err := s.indexOf(id)
+	if err != nil {
+		return err
+	}
+	record := journalRecord{Op: opFailed, ID: id}
+	if cause != nil {
+		record.Error = cause.Error()
+	}
+	if err := s.write(record); err != nil {
+		return err
+	}
+	s.entries[i].Attempts++
+	if cause != nil {
+		s.entries[i].LastError = record.Error
+	}
+	return nil
+}
+
+// Close will close the journal file. Any following operation returns a state error.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *FileStore) open() error {
+	return assert.State(s.file != nil, "outbox store is closed")
+}
+
+func (s *FileStore) indexOf(id int64) (int, error) {
+	for i, entry := range s.entries {
+		if entry.ID == id {
+			return i, nil
+		}
+	}
+	return -1, assert.State(false, fmt.Sprintf("outbox entry %d not found", id))
+}
+
+func (s *FileStore) encode(id int64, event domain.Event) ([]byte, error) {
+	return encode(s.codec, strconv.FormatInt(id, 10), event)
+}
+
+// write will append the supplied records to the journal, flushing them to the disk. On failure the journal is
+// truncated to its previous size, so that no partial line is left before the following records.
+func (s *FileStore) write(records ...journalRecord) error {
+	var buf []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+	if _, err := s.file.WriteAt(buf, s.size); err != nil {
+		s.file.Truncate(s.size)
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Truncate(s.size)
+		return err
+	}
+	s.size += int64(len(buf))
+	return nil
+}
+
+// replay will rebuild the pending entries from the journal. A last line left incomplete by a crash is ignored, since
+// the change it recorded was never acknowledged.
+func (s *FileStore) replay() error {
+	file, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<26)
+	for scanner.Scan() {
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			if scanner.Scan() {
+				return fmt.Errorf("outbox journal %s is corrupted: %w", s.path, err)
+			}
+			break
+		}
+		if record.ID > s.nextID {
+			s.nextID = record.ID
+		}
+		if err := s.apply(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// apply will apply the supplied journal record to the pending entries. Sequence records only carry the last assigned
+// id, while the records of entries no more in the journal are skipped.
+func (s *FileStore) apply(record journalRecord) error {
+	if record.Op == opSequence {
+		return nil
+	}
+	if record.Op == opAdd {
+		envelope, err := s.codec.Unmarshal(record.Data)
+		if err != nil {
+			return err
+		}
+		s.entries = append(s.entries, Entry{ID: record.ID, Event: envelope.Event(), CreatedAt: record.CreatedAt})
+		return nil
+	}
+	i, err := s.indexOf(record.ID)
+	if err != nil {
+		return nil
+	}
+	if record.Op == opPublished {
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		return nil
+	}
+	s.entries[i].Attempts++
+	if record.Error != "" {
+		s.entries[i].LastError = record.Error
+	}
+	return nil
+}
+
+// rewrite will replace the journal with one adding the pending entries only, opening it for appending.
+func (s *FileStore) rewrite() error {
+	tmp := s.path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.size = 0
+	records, err := s.records()
+	if err == nil {
+		err = s.write(records...)
+	}
+	if err == nil {
+		err = os.Rename(tmp, s.path)
+	}
+	if err != nil {
+		file.Close()
+		s.file = nil
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// records will return the journal records rebuilding the pending entries, preceded by the last assigned id so that
+// the ids of the published entries are not assigned again.
+func (s *FileStore) records() ([]journalRecord, error) {
+	records := []journalRecord{{Op: opSequence, ID: s.nextID}}
+	for _, entry := range s.entries {
+		data, err := s.encode(entry.ID, entry.Event)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, journalRecord{Op: opAdd, ID: entry.ID, Data: data, CreatedAt: entry.CreatedAt})
+		for i := 0; i < entry.Attempts; i++ {
+			records = append(records, journalRecord{Op: opFailed, ID: entry.ID, Error: entry.LastError})
+		}
+	}
+	return records, nil
+}
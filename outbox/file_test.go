@@ -0,0 +1,98 @@
+package outbox_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/outbox"
+	. "github.com/maurofran/kit/testing"
+)
+
+type namedEvent struct {
+	Name string
+}
+
+func (e namedEvent) Type() string {
+	return e.Name
+}
+
+func (e namedEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e namedEvent) Version() int {
+	return 1
+}
+
+func openFileStore(t *testing.T, path string) *outbox.FileStore {
+	r := codec.NewRegistry()
+	r.Register(namedEvent{"e1"}, namedEvent{"e2"}, namedEvent{"e3"}, namedEvent{"e4"})
+	c, _ := codec.NewJSONCodec(r)
+	s, err := outbox.NewFileStore(path, c)
+	Ok(t, err)
+	return s
+}
+
+func names(entries []outbox.Entry) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Event.Type()
+	}
+	return names
+}
+
+func TestFileStore_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.journal")
+	s := openFileStore(t, path)
+	Ok(t, s.Add(namedEvent{"e1"}, namedEvent{"e2"}, namedEvent{"e3"}))
+	Ok(t, s.MarkPublished(1))
+	Ok(t, s.MarkFailed(2, errors.New("boom")))
+	Ok(t, s.Close())
+
+	s = openFileStore(t, path)
+	entries, err := s.Pending(10, 3)
+	Ok(t, err)
+	Equals(t, []string{"e2", "e3"}, names(entries))
+	Equals(t, 1, entries[0].Attempts)
+	Equals(t, "boom", entries[0].LastError)
+	Ok(t, s.MarkPublished(2))
+	Ok(t, s.MarkPublished(3))
+	Ok(t, s.Close())
+
+	openFileStore(t, path).Close()
+	s = openFileStore(t, path)
+	defer s.Close()
+	Ok(t, s.Add(namedEvent{"e4"}))
+	entries, _ = s.Pending(10, 3)
+	Equals(t, []string{"e4"}, names(entries))
+	Equals(t, int64(4), entries[0].ID)
+}
+
+func TestFileStore_TornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.journal")
+	s := openFileStore(t, path)
+	s.Add(namedEvent{"e1"}, namedEvent{"e2"})
+	s.Close()
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	file.Write([]byte(`{"op":"published","id":`))
+	file.Close()
+	s = openFileStore(t, path)
+	defer s.Close()
+
+	entries, err := s.Pending(10, 3)
+	Ok(t, err)
+	Equals(t, []string{"e1", "e2"}, names(entries))
+}
+
+func TestFileStore_Closed(t *testing.T) {
+	s := openFileStore(t, filepath.Join(t.TempDir(), "outbox.journal"))
+	s.Close()
+	err := s.Add(namedEvent{"e1"})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+)
+
+// MemoryStore is a thread-safe outbox store keeping entries in memory.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+	nextID  int64
+}
+
+// NewMemoryStore will create a new empty in-memory outbox store.
+func NewMemoryStore() *MemoryStore {
+	return new(MemoryStore)
+}
+
+// Add will append the supplied events to the outbox.
+func (s *MemoryStore) Add(events ...domain.Event) error {
+	for _, event := range events {
+		if err := assert.NotNil(event, "event"); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, event := range events {
+		s.nextID++
+		s.entries = append(s.entries, Entry{ID: s.nextID, Event: event, CreatedAt: now})
+	}
+	return nil
+}
+
+// Pending will retrieve, in insertion order, up to limit unpublished entries that failed less than maxAttempts times.
+func (s *MemoryStore) Pending(limit, maxAttempts int) ([]Entry, error) {
+	if err := assert.IntMin(limit, 1, "limit"); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := make([]Entry, 0, limit)
+	for _, entry := range s.entries {
+		if len(entries) == limit {
+			break
+		}
+		if entry.Attempts < maxAttempts {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// MarkPublished will mark the entry with supplied id as published, removing it from the store.
+func (s *MemoryStore) MarkPublished(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, err := s.indexOf(id)
+	if err != nil {
+		return err
+	}
+	s.entries = append(s.entries[:i], s.entries[i+1:]...)
+	return nil
+}
+
+// MarkFailed will record a failed publishing attempt for the entry with supplied id.
+func (s *MemoryStore) MarkFailed(id int64, cause error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i, err := s.indexOf(id)
+	if err != nil {
+		return err
+	}
+	s.entries[i].Attempts++
+	if cause != nil {
+		s.entries[i].LastError = cause.Error()
+	}
+	return nil
+}
+
+func (s *MemoryStore) indexOf(id int64) (int, error) {
+	for i, entry := range s.entries {
+		if entry.ID == id {
+			return i, nil
+		}
+	}
+	return -1, assert.State(false, fmt.Sprintf("outbox entry %d not found", id))
+}
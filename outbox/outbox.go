@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/domain"
+)
+
+// Entry is a domain event waiting in the outbox to be published.
+type Entry struct {
+	// ID is the identifier of the entry, increasing in insertion order.
+	ID int64
+	// Event is the domain event to publish.
+	Event domain.Event
+	// CreatedAt is the instant the entry was added to the outbox.
+	CreatedAt time.Time
+	// Attempts is the number of failed publishing attempts.
+	Attempts int
+	// LastError is the message of the last publishing failure, if any.
+	LastError string
+}
+
+// Store is the interface implemented by outbox stores. To guarantee that no event is lost, events must be added in
+// the same transaction persisting the aggregate that recorded them, as the SQL store does with AddTx. The memory and
+// file stores take no part in that transaction, so they can not guarantee it.
+type Store interface {
+	// Add will append the supplied events to the outbox.
+	Add(events ...domain.Event) error
+	// Pending will retrieve, in insertion order, up to limit unpublished entries that failed less than maxAttempts
+	// times.
+	Pending(limit, maxAttempts int) ([]Entry, error)
+	// MarkPublished will mark the entry with supplied id as published.
+	MarkPublished(id int64) error
+	// MarkFailed will record a failed publishing attempt for the entry with supplied id.
+	MarkFailed(id int64, cause error) error
+}
+
+// Publisher is the interface implemented by objects publishing domain events outside of the process.
+type Publisher interface {
+	// Publish will publish the supplied event, returning an error if publishing failed.
+	Publish(domain.Event) error
+}
+
+// PublisherFunc is an adapter allowing to use ordinary functions as publishers.
+type PublisherFunc func(domain.Event) error
+
+// Publish will invoke the receiver function with supplied event.
+func (f PublisherFunc) Publish(event domain.Event) error {
+	return f(event)
+}
+
+// AddEventsOf will add the domain events recorded by supplied recorder to the outbox, clearing them only if they were
+// successfully added. The events are added outside of any transaction: use SQLStore.AddTx to add them atomically with
+// the aggregate.
+func AddEventsOf(store Store, recorder domain.EventRecorder) error {
+	if err := store.Add(recorder.DomainEvents()...); err != nil {
+		return err
+	}
+	recorder.ClearDomainEvents()
+	return nil
+}
+
+// encode will serialize the supplied event with supplied codec, enveloped with supplied event id.
+func encode(c codec.Codec, eventID string, event domain.Event) ([]byte, error) {
+	envelope, err := domain.NewEnvelope(eventID, "", 0, event, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Marshal(envelope)
+}
@@ -0,0 +1,89 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Relay is the object periodically publishing the pending entries of an outbox. Entries are marked as published only
+// after the publisher succeeded, so each event is published at least once.
+type Relay struct {
+	store       Store
+	publisher   Publisher
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+}
+
+// NewRelay will create a new relay publishing up to batchSize entries of store every interval. Each entry is retried
+// up to maxAttempts times before being left in the outbox for manual inspection.
+func NewRelay(store Store, publisher Publisher, interval time.Duration, batchSize, maxAttempts int) (*Relay, error) {
+	if err := assert.NotNil(store, "store"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(publisher, "publisher"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(interval > 0, "interval must be positive"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(batchSize, 1, "batchSize"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(maxAttempts, 1, "maxAttempts"); err != nil {
+		return nil, err
+	}
+	return &Relay{
+		store:       store,
+		publisher:   publisher,
+		interval:    interval,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+	}, nil
+}
+
+// Flush will publish a batch of pending entries, returning the number of published ones. Publishing stops at the
+// first failing entry to preserve ordering; the failure is recorded on the entry, and only store errors are returned.
+func (r *Relay) Flush() (int, error) {
+	entries, err := r.store.Pending(r.batchSize, r.maxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	published := 0
+	for _, entry := range entries {
+		if err := r.publisher.Publish(entry.Event); err != nil {
+			return published, r.store.MarkFailed(entry.ID, err)
+		}
+		if err := r.store.MarkPublished(entry.ID); err != nil {
+			return published, err
+		}
+		published++
+	}
+	return published, nil
+}
+
+// Run will flush the outbox every interval until the supplied context is done or a store error occurs. When a full
+// batch is published, the next one is flushed immediately.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		published, err := r.Flush()
+		if err != nil {
+			return err
+		}
+		if published == r.batchSize {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/outbox"
+	. "github.com/maurofran/kit/testing"
+)
+
+type testEvent struct {
+	name string
+}
+
+func (e testEvent) Type() string {
+	return e.name
+}
+
+func (e testEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e testEvent) Version() int {
+	return 1
+}
+
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []domain.Event
+	fail   map[string]int
+}
+
+func (p *recordingPublisher) Publish(event domain.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail[event.Type()] > 0 {
+		p.fail[event.Type()]--
+		return errors.New("unavailable")
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+func (p *recordingPublisher) published() []domain.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]domain.Event(nil), p.events...)
+}
+
+func aStore() *outbox.MemoryStore {
+	s := outbox.NewMemoryStore()
+	s.Add(testEvent{"e1"}, testEvent{"e2"}, testEvent{"e3"})
+	return s
+}
+
+func TestAddEventsOf(t *testing.T) {
+	s := outbox.NewMemoryStore()
+	ar := new(domain.AggregateRoot)
+	ar.RegisterEvent(testEvent{"e1"})
+	ar.RegisterEvent(testEvent{"e2"})
+	err := outbox.AddEventsOf(s, ar)
+
+	Ok(t, err)
+	Equals(t, 0, len(ar.DomainEvents()))
+	entries, _ := s.Pending(10, 1)
+	Equals(t, 2, len(entries))
+	Equals(t, int64(1), entries[0].ID)
+	Equals(t, testEvent{"e2"}, entries[1].Event)
+}
+
+func TestPending_ExcludesExhaustedEntries(t *testing.T) {
+	s := aStore()
+	s.MarkFailed(1, errors.New("boom"))
+	s.MarkFailed(1, errors.New("boom"))
+	entries, err := s.Pending(10, 2)
+
+	Ok(t, err)
+	Equals(t, 2, len(entries))
+	Equals(t, int64(2), entries[0].ID)
+}
+
+func TestMarkPublished_MissingEntry(t *testing.T) {
+	err := aStore().MarkPublished(42)
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestFlush_PublishesInOrder(t *testing.T) {
+	s := aStore()
+	p := new(recordingPublisher)
+	r, _ := outbox.NewRelay(s, p, time.Second, 2, 3)
+	published, err := r.Flush()
+
+	Ok(t, err)
+	Equals(t, 2, published)
+	Equals(t, []domain.Event{testEvent{"e1"}, testEvent{"e2"}}, p.published())
+	entries, _ := s.Pending(10, 3)
+	Equals(t, 1, len(entries))
+}
+
+func TestFlush_RetriesFailedEntries(t *testing.T) {
+	s := aStore()
+	p := &recordingPublisher{fail: map[string]int{"e2": 1}}
+	r, _ := outbox.NewRelay(s, p, time.Second, 10, 3)
+	published, err := r.Flush()
+
+	Ok(t, err)
+	Equals(t, 1, published)
+	entries, _ := s.Pending(10, 3)
+	Equals(t, 1, entries[0].Attempts)
+	Equals(t, "unavailable", entries[0].LastError)
+
+	published, err = r.Flush()
+
+	Ok(t, err)
+	Equals(t, 2, published)
+	Equals(t, []domain.Event{testEvent{"e1"}, testEvent{"e2"}, testEvent{"e3"}}, p.published())
+}
+
+func TestRun(t *testing.T) {
+	s := aStore()
+	p := new(recordingPublisher)
+	r, _ := outbox.NewRelay(s, p, time.Millisecond, 1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+	s.Add(testEvent{"e4"})
+	for len(p.published()) < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	Equals(t, context.Canceled, <-done)
+}
+
+func TestNewRelay_InvalidBatchSize(t *testing.T) {
+	_, err := outbox.NewRelay(aStore(), new(recordingPublisher), time.Second, 0, 1)
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
@@ -0,0 +1,174 @@
+package outbox
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/internal/sqlutil"
+)
+
+// sqlEventID is the event id of the envelopes encoding the events of the SQL store, whose entry ids are assigned by
+// the database on insert.
+const sqlEventID = "outbox"
+
+// Dialect is the set of database specific features used by the SQL outbox store.
+//
+// The schema of the store is made of the outbox_entries table, holding the pending entries with their encoded event,
+// creation instant in Unix nanoseconds, failed attempts and last error. Entry ids are assigned by the database.
+type Dialect = sqlutil.Dialect
+
+// SQLite is the dialect of SQLite databases.
+var SQLite = Dialect{
+	Schema: []string{
+		`CREATE TABLE IF NOT EXISTS outbox_entries (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			data       BLOB    NOT NULL,
+			created_at BIGINT  NOT NULL,
+			attempts   INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT    NOT NULL DEFAULT ''
+		)`,
+	},
+	Placeholder: sqlutil.QuestionMark,
+}
+
+// PostgreSQL is the dialect of PostgreSQL databases.
+var PostgreSQL = Dialect{
+	Schema: []string{
+		`CREATE TABLE IF NOT EXISTS outbox_entries (
+			id         BIGSERIAL PRIMARY KEY,
+			data       BYTEA     NOT NULL,
+			created_at BIGINT    NOT NULL,
+			attempts   INTEGER   NOT NULL DEFAULT 0,
+			last_error TEXT      NOT NULL DEFAULT ''
+		)`,
+	},
+	Placeholder: sqlutil.Numbered,
+}
+
+// SQLStore is an outbox store persisting entries in a relational database through database/sql. Events added with
+// AddTx are persisted in the transaction of the caller, so that they are committed atomically with the aggregate that
+// recorded them.
+type SQLStore struct {
+	db      *sql.DB
+	codec   codec.Codec
+	dialect Dialect
+}
+
+// NewSQLStore will create a new outbox store persisting entries in supplied database with supplied dialect. Events
+// are encoded with supplied codec.
+func NewSQLStore(db *sql.DB, c codec.Codec, dialect Dialect) (*SQLStore, error) {
+	if err := assert.NotNil(db, "db"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(c, "codec"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(dialect.Placeholder, "dialect.Placeholder"); err != nil {
+		return nil, err
+	}
+	return &SQLStore{db: db, codec: c, dialect: dialect}, nil
+}
+
+// CreateSchema will create the tables of the store, if not existing.
+func (s *SQLStore) CreateSchema() error {
+	return s.dialect.CreateSchema(s.db)
+}
+
+// Add will append the supplied events to the outbox, in a transaction of their own.
+func (s *SQLStore) Add(events ...domain.Event) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := s.AddTx(tx, events...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// AddTx will append the supplied events to the outbox in supplied transaction, so that they are added only if the
+// transaction is committed. The transaction is neither committed nor rolled back.
+func (s *SQLStore) AddTx(tx *sql.Tx, events ...domain.Event) error {
+	if err := assert.NotNil(tx, "tx"); err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := assert.NotNil(event, "event"); err != nil {
+			return err
+		}
+	}
+	now := time.Now().UnixNano()
+	insert := s.dialect.Bind("INSERT INTO outbox_entries (data, created_at) VALUES (?, ?)")
+	for _, event := range events {
+		data, err := encode(s.codec, sqlEventID, event)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(insert, data, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending will retrieve, in insertion order, up to limit unpublished entries that failed less than maxAttempts times.
+func (s *SQLStore) Pending(limit, maxAttempts int) ([]Entry, error) {
+	if err := assert.IntMin(limit, 1, "limit"); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(s.dialect.Bind("SELECT id, data, created_at, attempts, last_error FROM outbox_entries "+
+		"WHERE attempts < ? ORDER BY id LIMIT ?"), maxAttempts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]Entry, 0, limit)
+	for rows.Next() {
+		var entry Entry
+		var data []byte
+		var createdAt int64
+		if err := rows.Scan(&entry.ID, &data, &createdAt, &entry.Attempts, &entry.LastError); err != nil {
+			return nil, err
+		}
+		envelope, err := s.codec.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+		entry.Event = envelope.Event()
+		entry.CreatedAt = time.Unix(0, createdAt)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// MarkPublished will mark the entry with supplied id as published, removing it from the store.
+func (s *SQLStore) MarkPublished(id int64) error {
+	return s.update(id, "DELETE FROM outbox_entries WHERE id = ?", id)
+}
+
+// MarkFailed will record a failed publishing attempt for the entry with supplied id.
+func (s *SQLStore) MarkFailed(id int64, cause error) error {
+	if cause == nil {
+		return s.update(id, "UPDATE outbox_entries SET attempts = attempts + 1 WHERE id = ?", id)
+	}
+	return s.update(id, "UPDATE outbox_entries SET attempts = attempts + 1, last_error = ? WHERE id = ?", cause.Error(),
+		id)
+}
+
+// update will execute the supplied statement, returning a state error if the entry with supplied id was not found.
+func (s *SQLStore) update(id int64, statement string, args ...interface{}) error {
+	result, err := s.db.Exec(s.dialect.Bind(statement), args...)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	return assert.State(rows == 1, fmt.Sprintf("outbox entry %d not found", id))
+}
@@ -0,0 +1,17 @@
+package outbox_test
+
+import (
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/outbox"
+	. "github.com/maurofran/kit/testing"
+)
+
+func TestNewSQLStore_NilDB(t *testing.T) {
+	c, _ := codec.NewJSONCodec(codec.NewRegistry())
+	_, err := outbox.NewSQLStore(nil, c, outbox.SQLite)
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
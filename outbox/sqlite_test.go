@@ -0,0 +1,66 @@
+//go:build sqlite
+// +build sqlite
+
+// The SQL outbox store tests run against SQLite when the sqlite build tag is supplied, after adding the
+// modernc.org/sqlite driver, which is not a dependency of the module:
+//
+//	go get modernc.org/sqlite
+//	go test -tags sqlite ./outbox
+package outbox_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/codec"
+	"github.com/maurofran/kit/outbox"
+	. "github.com/maurofran/kit/testing"
+	_ "modernc.org/sqlite"
+)
+
+func aSQLStore(t *testing.T) (*outbox.SQLStore, *sql.DB) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "outbox.db"))
+	Ok(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	r := codec.NewRegistry()
+	r.Register(namedEvent{"e1"}, namedEvent{"e2"}, namedEvent{"e3"})
+	c, _ := codec.NewJSONCodec(r)
+	s, err := outbox.NewSQLStore(db, c, outbox.SQLite)
+	Ok(t, err)
+	Ok(t, s.CreateSchema())
+	Ok(t, s.CreateSchema())
+	return s, db
+}
+
+func TestSQLStore_AddTx(t *testing.T) {
+	s, db := aSQLStore(t)
+	tx, _ := db.Begin()
+	Ok(t, s.AddTx(tx, namedEvent{"e1"}))
+	Ok(t, tx.Rollback())
+	tx, _ = db.Begin()
+	Ok(t, s.AddTx(tx, namedEvent{"e2"}, namedEvent{"e3"}))
+	Ok(t, tx.Commit())
+
+	entries, err := s.Pending(10, 1)
+	Ok(t, err)
+	Equals(t, []string{"e2", "e3"}, names(entries))
+}
+
+func TestSQLStore_Mark(t *testing.T) {
+	s, _ := aSQLStore(t)
+	Ok(t, s.Add(namedEvent{"e1"}, namedEvent{"e2"}, namedEvent{"e3"}))
+	entries, _ := s.Pending(10, 2)
+
+	Ok(t, s.MarkPublished(entries[0].ID))
+	Ok(t, s.MarkFailed(entries[1].ID, errors.New("boom")))
+	Ok(t, s.MarkFailed(entries[1].ID, errors.New("boom")))
+	entries, err := s.Pending(10, 2)
+	Ok(t, err)
+	Equals(t, []string{"e3"}, names(entries))
+	err = s.MarkPublished(42)
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
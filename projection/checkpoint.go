@@ -0,0 +1,37 @@
+package projection
+
+import "sync"
+
+// CheckpointStore is the interface implemented by stores tracking the global position processed by each projection.
+type CheckpointStore interface {
+	// Load will retrieve the last position processed by the projection with supplied name, zero if none.
+	Load(name string) (int64, error)
+	// Save will store the last position processed by the projection with supplied name.
+	Save(name string, position int64) error
+}
+
+// MemoryCheckpointStore is a thread-safe checkpoint store keeping positions in memory.
+type MemoryCheckpointStore struct {
+	mu        sync.RWMutex
+	positions map[string]int64
+}
+
+// NewMemoryCheckpointStore will create a new empty in-memory checkpoint store.
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{positions: make(map[string]int64)}
+}
+
+// Load will retrieve the last position processed by the projection with supplied name, zero if none.
+func (s *MemoryCheckpointStore) Load(name string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.positions[name], nil
+}
+
+// Save will store the last position processed by the projection with supplied name.
+func (s *MemoryCheckpointStore) Save(name string, position int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.positions[name] = position
+	return nil
+}
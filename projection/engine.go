@@ -0,0 +1,132 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/eventstore"
+)
+
+// Engine is the object feeding projections with the events of a store. Each event is delivered at least once: the
+// checkpoint of a projection is saved after each handled event.
+type Engine struct {
+	mu          sync.Mutex
+	store       eventstore.Store
+	checkpoints CheckpointStore
+	interval    time.Duration
+	projections map[string]*Projection
+	names       []string
+}
+
+// NewEngine will create a new engine reading events from supplied store and tracking the projections progress in
+// supplied checkpoint store. While running, the store is polled for new events every interval.
+func NewEngine(store eventstore.Store, checkpoints CheckpointStore, interval time.Duration) (*Engine, error) {
+	if err := assert.NotNil(store, "store"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(checkpoints, "checkpoints"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(interval > 0, "interval must be positive"); err != nil {
+		return nil, err
+	}
+	return &Engine{
+		store:       store,
+		checkpoints: checkpoints,
+		interval:    interval,
+		projections: make(map[string]*Projection),
+	}, nil
+}
+
+// Register will add the supplied projection to the engine. Projection names must be unique.
+func (e *Engine) Register(projection *Projection) error {
+	if err := assert.NotNil(projection, "projection"); err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.projections[projection.Name()]; ok {
+		return assert.State(false, fmt.Sprintf("projection %s is already registered", projection.Name()))
+	}
+	e.projections[projection.Name()] = projection
+	e.names = append(e.names, projection.Name())
+	return nil
+}
+
+// CatchUp will deliver to each projection the events appended after its checkpoint.
+func (e *Engine) CatchUp() error {
+	return e.catchUpAll(context.Background())
+}
+
+// Run will catch up all the projections, then keep them updated polling the store until the supplied context is done
+// or an error occurs.
+func (e *Engine) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		if err := e.catchUpAll(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rebuild will reset the read model and the checkpoint of the projection with supplied name, replaying all the
+// events of the store.
+func (e *Engine) Rebuild(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	projection, ok := e.projections[name]
+	if err := assert.State(ok, fmt.Sprintf("projection %s is not registered", name)); err != nil {
+		return err
+	}
+	if projection.reset != nil {
+		if err := projection.reset(); err != nil {
+			return err
+		}
+	}
+	if err := e.checkpoints.Save(name, 0); err != nil {
+		return err
+	}
+	return e.catchUp(context.Background(), projection)
+}
+
+func (e *Engine) catchUpAll(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, name := range e.names {
+		if err := e.catchUp(ctx, e.projections[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) catchUp(ctx context.Context, projection *Projection) error {
+	checkpoint, err := e.checkpoints.Load(projection.Name())
+	if err != nil {
+		return err
+	}
+	it, err := eventstore.Stream(ctx, e.store, eventstore.Filter{From: checkpoint + 1})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		record := it.Record()
+		if err := projection.handle(record); err != nil {
+			return fmt.Errorf("projection %s failed at position %d: %w", projection.Name(), record.Position, err)
+		}
+		if err := e.checkpoints.Save(projection.Name(), record.Position); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
@@ -0,0 +1,162 @@
+package projection_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/eventstore"
+	"github.com/maurofran/kit/projection"
+	. "github.com/maurofran/kit/testing"
+)
+
+type testEvent struct {
+	name string
+}
+
+func (e testEvent) Type() string {
+	return e.name
+}
+
+func (e testEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e testEvent) Version() int {
+	return 1
+}
+
+type countModel struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (m *countModel) projection(name string) *projection.Projection {
+	m.counts = make(map[string]int)
+	increment := func(record eventstore.Record) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.counts[record.StreamID]++
+		return nil
+	}
+	p, _ := projection.New(name)
+	return p.On("Created", increment).On("Updated", increment).OnReset(func() error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.counts = make(map[string]int)
+		return nil
+	})
+}
+
+func (m *countModel) count(streamID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counts[streamID]
+}
+
+func aStore() *eventstore.MemoryStore {
+	s := eventstore.NewMemoryStore()
+	s.Append("stream-1", eventstore.NoStream, testEvent{"Created"}, testEvent{"Updated"}, testEvent{"Ignored"})
+	s.Append("stream-2", eventstore.NoStream, testEvent{"Created"})
+	return s
+}
+
+func TestCatchUp(t *testing.T) {
+	s := aStore()
+	checkpoints := projection.NewMemoryCheckpointStore()
+	e, _ := projection.NewEngine(s, checkpoints, time.Second)
+	m := new(countModel)
+	e.Register(m.projection("counts"))
+	err := e.CatchUp()
+
+	Ok(t, err)
+	Equals(t, 2, m.count("stream-1"))
+	Equals(t, 1, m.count("stream-2"))
+	position, _ := checkpoints.Load("counts")
+	Equals(t, int64(4), position)
+
+	s.Append("stream-2", 1, testEvent{"Updated"})
+	err = e.CatchUp()
+
+	Ok(t, err)
+	Equals(t, 2, m.count("stream-1"))
+	Equals(t, 2, m.count("stream-2"))
+}
+
+func TestCatchUp_FromCheckpoint(t *testing.T) {
+	checkpoints := projection.NewMemoryCheckpointStore()
+	checkpoints.Save("counts", 2)
+	e, _ := projection.NewEngine(aStore(), checkpoints, time.Second)
+	m := new(countModel)
+	e.Register(m.projection("counts"))
+	err := e.CatchUp()
+
+	Ok(t, err)
+	Equals(t, 0, m.count("stream-1"))
+	Equals(t, 1, m.count("stream-2"))
+}
+
+func TestCatchUp_HandlerFailure(t *testing.T) {
+	checkpoints := projection.NewMemoryCheckpointStore()
+	e, _ := projection.NewEngine(aStore(), checkpoints, time.Second)
+	p, _ := projection.New("failing")
+	p.On("Updated", func(eventstore.Record) error {
+		return errors.New("boom")
+	})
+	e.Register(p)
+	err := e.CatchUp()
+
+	Assert(t, err != nil, "should return an error")
+	position, _ := checkpoints.Load("failing")
+	Equals(t, int64(1), position)
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	e, _ := projection.NewEngine(aStore(), projection.NewMemoryCheckpointStore(), time.Second)
+	e.Register(new(countModel).projection("counts"))
+	err := e.Register(new(countModel).projection("counts"))
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestRebuild(t *testing.T) {
+	e, _ := projection.NewEngine(aStore(), projection.NewMemoryCheckpointStore(), time.Second)
+	m := new(countModel)
+	e.Register(m.projection("counts"))
+	e.CatchUp()
+	err := e.Rebuild("counts")
+
+	Ok(t, err)
+	Equals(t, 2, m.count("stream-1"))
+	Equals(t, 1, m.count("stream-2"))
+}
+
+func TestRebuild_Unknown(t *testing.T) {
+	e, _ := projection.NewEngine(aStore(), projection.NewMemoryCheckpointStore(), time.Second)
+	err := e.Rebuild("missing")
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestRun(t *testing.T) {
+	s := aStore()
+	e, _ := projection.NewEngine(s, projection.NewMemoryCheckpointStore(), time.Millisecond)
+	m := new(countModel)
+	e.Register(m.projection("counts"))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- e.Run(ctx)
+	}()
+	s.Append("stream-3", eventstore.NoStream, testEvent{"Created"})
+	for m.count("stream-3") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	Equals(t, context.Canceled, <-done)
+	Equals(t, 2, m.count("stream-1"))
+}
@@ -0,0 +1,55 @@
+package projection
+
+import (
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/eventstore"
+)
+
+// HandlerFunc is the function updating a read model with a persisted event.
+type HandlerFunc func(eventstore.Record) error
+
+// Projection is a named read model built from domain events, whose handlers are registered by event type.
+type Projection struct {
+	name     string
+	handlers map[string]HandlerFunc
+	reset    func() error
+}
+
+// New will create a new projection with supplied name, unique inside an engine.
+func New(name string) (*Projection, error) {
+	if err := assert.NotEmpty(name, "name"); err != nil {
+		return nil, err
+	}
+	return &Projection{name: name, handlers: make(map[string]HandlerFunc)}, nil
+}
+
+// Name will return the name of the projection.
+func (p *Projection) Name() string {
+	return p.name
+}
+
+// On will register the handler for the events of supplied type, returning the receiver projection.
+func (p *Projection) On(eventType string, handler HandlerFunc) *Projection {
+	p.handlers[eventType] = handler
+	return p
+}
+
+// OnReset will register the function clearing the read model when the projection is rebuilt, returning the receiver
+// projection.
+func (p *Projection) OnReset(reset func() error) *Projection {
+	p.reset = reset
+	return p
+}
+
+// Handles will check if the projection handles the events of supplied type.
+func (p *Projection) Handles(eventType string) bool {
+	_, ok := p.handlers[eventType]
+	return ok
+}
+
+func (p *Projection) handle(record eventstore.Record) error {
+	if handler, ok := p.handlers[record.Event.Type()]; ok {
+		return handler(record)
+	}
+	return nil
+}
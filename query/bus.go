@@ -0,0 +1,89 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Bus is the thread-safe query bus routing each query to the single handler registered for its type, through a chain
+// of middlewares.
+type Bus struct {
+	mu          sync.RWMutex
+	handlers    map[string]Handler
+	middlewares []Middleware
+}
+
+// NewBus will create a new query bus applying supplied middlewares, the first one being the outermost.
+func NewBus(middlewares ...Middleware) *Bus {
+	return &Bus{handlers: make(map[string]Handler), middlewares: middlewares}
+}
+
+// Register will register the untyped handler for the queries of supplied type. Only one handler can be registered
+// for each query type.
+func (b *Bus) Register(queryType string, handler Handler) error {
+	if err := assert.NotEmpty(queryType, "queryType"); err != nil {
+		return err
+	}
+	if err := assert.NotNil(handler, "handler"); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.handlers[queryType]; ok {
+		return assert.State(false, fmt.Sprintf("a handler is already registered for query type %s", queryType))
+	}
+	for i := len(b.middlewares) - 1; i >= 0; i-- {
+		handler = b.middlewares[i](handler)
+	}
+	b.handlers[queryType] = handler
+	return nil
+}
+
+// Dispatch will execute the supplied query with its registered handler, returning an untyped result.
+func (b *Bus) Dispatch(ctx context.Context, query Query) (interface{}, error) {
+	if err := assert.NotNil(query, "query"); err != nil {
+		return nil, err
+	}
+	b.mu.RLock()
+	handler, ok := b.handlers[query.QueryType()]
+	b.mu.RUnlock()
+	if !ok {
+		return nil, noHandlerError{query.QueryType()}
+	}
+	return handler.Handle(ctx, query)
+}
+
+// Register will register the typed handler for the queries of supplied type on supplied bus.
+func Register[Q Query, R any](bus *Bus, queryType string, handler func(context.Context, Q) (R, error)) error {
+	if err := assert.NotNil(handler, "handler"); err != nil {
+		return err
+	}
+	return bus.Register(queryType, HandlerFunc(func(ctx context.Context, query Query) (interface{}, error) {
+		q, ok := query.(Q)
+		if err := assert.Condition(ok, fmt.Sprintf("query of type %T is not supported", query)); err != nil {
+			return nil, err
+		}
+		return handler(ctx, q)
+	}))
+}
+
+// Ask will execute the supplied query on supplied bus, returning its typed result.
+func Ask[R any](ctx context.Context, bus *Bus, query Query) (R, error) {
+	var zero R
+	result, err := bus.Dispatch(ctx, query)
+	if err != nil {
+		return zero, err
+	}
+	if result == nil {
+		return zero, nil
+	}
+	r, ok := result.(R)
+	if err := assert.State(ok, fmt.Sprintf("query %s returned %T, expected %T", query.QueryType(), result,
+		zero)); err != nil {
+		return zero, err
+	}
+	return r, nil
+}
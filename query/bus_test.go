@@ -0,0 +1,222 @@
+package query_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+	"github.com/maurofran/kit/query"
+	. "github.com/maurofran/kit/testing"
+)
+
+type orderByID struct {
+	id string
+}
+
+func (q orderByID) QueryType() string {
+	return "OrderByID"
+}
+
+type order struct {
+	ID     string
+	Tenant string
+}
+
+type slowQuery struct{}
+
+func (q slowQuery) QueryType() string {
+	return "Slow"
+}
+
+type scopedQuery struct {
+	md *metadata.Container
+}
+
+func (q scopedQuery) QueryType() string {
+	return "Scoped"
+}
+
+func (q scopedQuery) Metadata() *metadata.Container {
+	return q.md
+}
+
+func aBus(calls *int, middlewares ...query.Middleware) *query.Bus {
+	b := query.NewBus(middlewares...)
+	query.Register(b, "OrderByID", func(ctx context.Context, q orderByID) (order, error) {
+		*calls++
+		if q.id == "" {
+			return order{}, errors.New("not found")
+		}
+		tenant, _ := metadata.FromContext(ctx).Get(domain.TenantKey)
+		s, _ := tenant.(string)
+		return order{q.id, s}, nil
+	})
+	return b
+}
+
+func tenantContext(tenant string) context.Context {
+	return metadata.NewContext(context.Background(), metadata.With(domain.TenantKey, tenant))
+}
+
+func TestAsk(t *testing.T) {
+	calls := 0
+	o, err := query.Ask[order](tenantContext("acme"), aBus(&calls), orderByID{"order-1"})
+
+	Ok(t, err)
+	Equals(t, order{"order-1", "acme"}, o)
+}
+
+func TestAsk_WrongResultType(t *testing.T) {
+	calls := 0
+	_, err := query.Ask[string](context.Background(), aBus(&calls), orderByID{"order-1"})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestAsk_NoHandler(t *testing.T) {
+	_, err := query.Ask[order](context.Background(), query.NewBus(), orderByID{"order-1"})
+
+	Assert(t, query.IsNoHandlerError(err), "should return a no handler error")
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	calls := 0
+	err := query.Register(aBus(&calls), "OrderByID", func(context.Context, orderByID) (order, error) {
+		return order{}, nil
+	})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
+
+func TestCaching(t *testing.T) {
+	calls := 0
+	b := aBus(&calls, query.Caching(time.Minute, query.ScopedKey))
+	first, _ := query.Ask[order](tenantContext("acme"), b, orderByID{"order-1"})
+	second, _ := query.Ask[order](tenantContext("acme"), b, orderByID{"order-1"})
+
+	Equals(t, 1, calls)
+	Equals(t, first, second)
+
+	other, _ := query.Ask[order](tenantContext("other"), b, orderByID{"order-1"})
+
+	Equals(t, 2, calls)
+	Equals(t, "other", other.Tenant)
+}
+
+type orderLines []string
+
+func (l orderLines) Clone() interface{} {
+	return append(orderLines(nil), l...)
+}
+
+func TestCaching_ClonesResults(t *testing.T) {
+	b := query.NewBus(query.Caching(time.Minute, query.ScopedKey))
+	query.Register(b, "OrderByID", func(ctx context.Context, q orderByID) (orderLines, error) {
+		return orderLines{"line-1", "line-2"}, nil
+	})
+	first, err := query.Ask[orderLines](context.Background(), b, orderByID{"order-1"})
+	Ok(t, err)
+	first[0] = "changed"
+	second, _ := query.Ask[orderLines](context.Background(), b, orderByID{"order-1"})
+	second[1] = "changed"
+	third, _ := query.Ask[orderLines](context.Background(), b, orderByID{"order-1"})
+
+	Equals(t, orderLines{"line-1", "line-2"}, third)
+}
+
+type tenantOrders struct {
+	md     *metadata.Container
+	status string
+}
+
+func (q *tenantOrders) QueryType() string {
+	return "TenantOrders"
+}
+
+func (q *tenantOrders) Metadata() *metadata.Container {
+	return q.md
+}
+
+func TestCaching_PropagatedTenants(t *testing.T) {
+	calls := 0
+	b := query.NewBus(query.PropagateMetadata(), query.Caching(time.Minute, query.ScopedKey))
+	query.Register(b, "TenantOrders", func(ctx context.Context, q *tenantOrders) (string, error) {
+		calls++
+		tenant, _ := metadata.FromContext(ctx).Get(domain.TenantKey)
+		return tenant.(string) + ":" + q.status, nil
+	})
+	ask := func(tenant string) string {
+		result, err := query.Ask[string](context.Background(), b, &tenantOrders{
+			metadata.With(domain.TenantKey, tenant), "open",
+		})
+		Ok(t, err)
+		return result
+	}
+
+	Equals(t, "acme:open", ask("acme"))
+	Equals(t, "acme:open", ask("acme"))
+	Equals(t, 1, calls)
+	Equals(t, "other:open", ask("other"))
+	Equals(t, "other:open", ask("other"))
+	Equals(t, 2, calls)
+}
+
+func TestScopedKey(t *testing.T) {
+	ctx := tenantContext("acme")
+	first, ok := query.ScopedKey(ctx, &tenantOrders{metadata.With("page", 1), "open"})
+	Assert(t, ok, "should cache the query")
+	second, _ := query.ScopedKey(ctx, &tenantOrders{metadata.With("page", 1), "open"})
+	Equals(t, first, second)
+	other, _ := query.ScopedKey(ctx, &tenantOrders{metadata.With("page", 1.0), "open"})
+	Assert(t, first != other, "should distinguish the values of different types")
+	_, ok = query.ScopedKey(ctx, &tenantOrders{metadata.With("page", func() {}), "open"})
+	Assert(t, !ok, "should not cache a query holding a function")
+}
+
+func TestCaching_SkipsErrors(t *testing.T) {
+	calls := 0
+	b := aBus(&calls, query.Caching(time.Minute, query.ScopedKey))
+	query.Ask[order](context.Background(), b, orderByID{})
+	_, err := query.Ask[order](context.Background(), b, orderByID{})
+
+	Equals(t, errors.New("not found"), err)
+	Equals(t, 2, calls)
+}
+
+func TestCaching_Expired(t *testing.T) {
+	calls := 0
+	b := aBus(&calls, query.Caching(0, query.ScopedKey))
+	query.Ask[order](context.Background(), b, orderByID{"order-1"})
+	query.Ask[order](context.Background(), b, orderByID{"order-1"})
+
+	Equals(t, 2, calls)
+}
+
+func TestTimeout(t *testing.T) {
+	b := query.NewBus(query.Timeout(10 * time.Millisecond))
+	release := make(chan struct{})
+	defer close(release)
+	query.Register(b, "Slow", func(context.Context, slowQuery) (string, error) {
+		<-release
+		return "late", nil
+	})
+	_, err := query.Ask[string](context.Background(), b, slowQuery{})
+
+	Equals(t, context.DeadlineExceeded, err)
+}
+
+func TestPropagateMetadata(t *testing.T) {
+	b := query.NewBus(query.PropagateMetadata())
+	query.Register(b, "Scoped", func(ctx context.Context, q scopedQuery) (string, error) {
+		user, _ := metadata.FromContext(ctx).Get(domain.UserKey)
+		return user.(string), nil
+	})
+	user, err := query.Ask[string](context.Background(), b, scopedQuery{metadata.With(domain.UserKey, "john")})
+
+	Ok(t, err)
+	Equals(t, "john", user)
+}
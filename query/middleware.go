@@ -0,0 +1,226 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+)
+
+// KeyFunc is the function computing the cache key of a query. Queries returning false are not cached.
+type KeyFunc func(ctx context.Context, query Query) (string, bool)
+
+// ScopedKey is the key function identifying a query by its type and value, scoped by the tenant and the user found
+// in the context metadata so that cached results never leak between them. Pointers are followed, so that queries
+// equal in value share the same key, while queries holding functions, channels or cyclic pointers are not cached.
+//
+// The tenant and the user carried by queries implementing metadata.Carrier are found in the context only if the
+// PropagateMetadata middleware precedes the Caching one in the middlewares of the bus.
+func ScopedKey(ctx context.Context, query Query) (string, bool) {
+	md := metadata.FromContext(ctx)
+	tenant, _ := md.Get(domain.TenantKey)
+	user, _ := md.Get(domain.UserKey)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v|%v|%s|", tenant, user, query.QueryType())
+	if !writeValue(&b, reflect.ValueOf(query), make(map[uintptr]bool)) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// writeValue will write the supplied value to the builder, following pointers and interfaces and sorting map entries,
+// so that values equal in content are written the same. False is returned if the value can not be written.
+func writeValue(b *strings.Builder, v reflect.Value, visiting map[uintptr]bool) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		b.WriteString("nil")
+	case reflect.Ptr:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return true
+		}
+		if visiting[v.Pointer()] {
+			return false
+		}
+		visiting[v.Pointer()] = true
+		defer delete(visiting, v.Pointer())
+		b.WriteByte('&')
+		return writeValue(b, v.Elem(), visiting)
+	case reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("nil")
+			return true
+		}
+		b.WriteString(v.Elem().Type().String())
+		b.WriteByte('(')
+		if !writeValue(b, v.Elem(), visiting) {
+			return false
+		}
+		b.WriteByte(')')
+	case reflect.Struct:
+		b.WriteString(v.Type().String())
+		b.WriteByte('{')
+		for i := 0; i < v.NumField(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			b.WriteString(v.Type().Field(i).Name)
+			b.WriteByte(':')
+			if !writeValue(b, v.Field(i), visiting) {
+				return false
+			}
+		}
+		b.WriteByte('}')
+	case reflect.Slice, reflect.Array:
+		b.WriteString(v.Type().String())
+		b.WriteByte('{')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if !writeValue(b, v.Index(i), visiting) {
+				return false
+			}
+		}
+		b.WriteByte('}')
+	case reflect.Map:
+		entries := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			var entry strings.Builder
+			if !writeValue(&entry, iter.Key(), visiting) {
+				return false
+			}
+			entry.WriteByte(':')
+			if !writeValue(&entry, iter.Value(), visiting) {
+				return false
+			}
+			entries = append(entries, entry.String())
+		}
+		sort.Strings(entries)
+		b.WriteString(v.Type().String())
+		b.WriteByte('{')
+		b.WriteString(strings.Join(entries, ","))
+		b.WriteByte('}')
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Complex64, reflect.Complex128:
+		b.WriteString(strconv.FormatComplex(v.Complex(), 'g', -1, 128))
+	case reflect.String:
+		b.WriteString(strconv.Quote(v.String()))
+	default:
+		return false
+	}
+	return true
+}
+
+// Cloner is the interface implemented by query results copying themselves, so that the caching middleware never
+// shares them between callers.
+type Cloner interface {
+	// Clone will return a copy of the result not sharing any mutable state with it.
+	Clone() interface{}
+}
+
+// clone will return a copy of supplied result if it implements Cloner, the result itself otherwise.
+func clone(result interface{}) interface{} {
+	if c, ok := result.(Cloner); ok {
+		return c.Clone()
+	}
+	return result
+}
+
+type cacheEntry struct {
+	result    interface{}
+	expiresAt time.Time
+}
+
+// Caching will return a middleware caching successful results for supplied time to live, identifying queries with
+// supplied key function. Key functions reading the context metadata, as ScopedKey, require the PropagateMetadata
+// middleware to precede this one, as in NewBus(PropagateMetadata(), Caching(ttl, ScopedKey)).
+//
+// Results implementing Cloner are copied when cached and when returned. The other results are shared by all the
+// callers of the query until expired, so they must not be mutated: return them by value, without maps, slices or
+// pointers, or implement Cloner.
+func Caching(ttl time.Duration, key KeyFunc) Middleware {
+	var mu sync.Mutex
+	entries := make(map[string]cacheEntry)
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, query Query) (interface{}, error) {
+			k, ok := key(ctx, query)
+			if !ok {
+				return next.Handle(ctx, query)
+			}
+			now := time.Now()
+			mu.Lock()
+			entry, found := entries[k]
+			mu.Unlock()
+			if found && now.Before(entry.expiresAt) {
+				return clone(entry.result), nil
+			}
+			result, err := next.Handle(ctx, query)
+			if err != nil {
+				return nil, err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for key, entry := range entries {
+				if !now.Before(entry.expiresAt) {
+					delete(entries, key)
+				}
+			}
+			entries[k] = cacheEntry{clone(result), now.Add(ttl)}
+			return result, nil
+		})
+	}
+}
+
+type timeoutResult struct {
+	result interface{}
+	err    error
+}
+
+// Timeout will return a middleware bounding the execution time of each query. The handler receives a context
+// cancelled after the timeout, and the query fails with context.DeadlineExceeded even if the handler ignores it.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, query Query) (interface{}, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			done := make(chan timeoutResult, 1)
+			go func() {
+				result, err := next.Handle(ctx, query)
+				done <- timeoutResult{result, err}
+			}()
+			select {
+			case r := <-done:
+				return r.result, r.err
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		})
+	}
+}
+
+// PropagateMetadata will return a middleware adding the metadata of queries implementing metadata.Carrier to the
+// metadata of the context, so that handlers can scope their reads with metadata.FromContext. Context metadata take
+// precedence.
+func PropagateMetadata() Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, query Query) (interface{}, error) {
+			return next.Handle(metadata.Propagate(ctx, query), query)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package query
+
+import (
+	"context"
+	"fmt"
+)
+
+// Query is the interface implemented by queries, requests to read the state of the system.
+type Query interface {
+	// QueryType will return the type used to route the query to its handler.
+	QueryType() string
+}
+
+// Handler is the interface implemented by untyped query handlers, used by middlewares.
+type Handler interface {
+	// Handle will execute the supplied query, returning its result.
+	Handle(ctx context.Context, query Query) (interface{}, error)
+}
+
+// HandlerFunc is an adapter allowing to use ordinary functions as untyped query handlers.
+type HandlerFunc func(ctx context.Context, query Query) (interface{}, error)
+
+// Handle will invoke the receiver function with supplied context and query.
+func (f HandlerFunc) Handle(ctx context.Context, query Query) (interface{}, error) {
+	return f(ctx, query)
+}
+
+// Middleware is the function decorating a query handler with additional behaviour.
+type Middleware func(Handler) Handler
+
+type noHandlerError struct {
+	queryType string
+}
+
+func (err noHandlerError) Error() string {
+	return fmt.Sprintf("no handler registered for query type %s", err.queryType)
+}
+
+// IsNoHandlerError verify if the supplied error is raised for a query without registered handler.
+func IsNoHandlerError(err error) bool {
+	_, ok := err.(noHandlerError)
+	return ok
+}
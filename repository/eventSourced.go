@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/eventstore"
+)
+
+// EventSourcedAggregate is the interface implemented by aggregates rebuilt from their events. Aggregates embedding
+// domain.EventSourcedAggregateRoot only need to implement ID.
+type EventSourcedAggregate interface {
+	Aggregate
+	// LoadFromHistory will replay the supplied events on the aggregate.
+	LoadFromHistory([]domain.Event) error
+}
+
+// EventSourced is a repository persisting aggregates as streams of events, identified by the aggregate id.
+type EventSourced[T EventSourcedAggregate] struct {
+	store   eventstore.Store
+	factory func(id string) T
+}
+
+// NewEventSourced will create a new repository storing events in supplied store. The factory is used to create the
+// empty aggregates on which the events are replayed.
+func NewEventSourced[T EventSourcedAggregate](store eventstore.Store, factory func(id string) T) (*EventSourced[T],
+	error) {
+	if err := assert.NotNil(store, "store"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(factory, "factory"); err != nil {
+		return nil, err
+	}
+	return &EventSourced[T]{store, factory}, nil
+}
+
+// Load will rebuild the aggregate with supplied id from its events.
+func (r *EventSourced[T]) Load(id string) (T, error) {
+	var zero T
+	records, err := r.store.Load(id, 1)
+	if err != nil {
+		return zero, err
+	}
+	if len(records) == 0 {
+		return zero, notFoundError{id}
+	}
+	aggregate := r.factory(id)
+	if err := aggregate.LoadFromHistory(eventstore.Events(records)); err != nil {
+		return zero, err
+	}
+	return aggregate, nil
+}
+
+// Save will append the uncommitted events of supplied aggregate to its stream, checking that no other event was
+// appended since the aggregate was loaded.
+func (r *EventSourced[T]) Save(aggregate T) ([]domain.Event, error) {
+	if err := assert.NotNil(aggregate, "aggregate"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotEmpty(aggregate.ID(), "aggregate.ID"); err != nil {
+		return nil, err
+	}
+	events := aggregate.DomainEvents()
+	if len(events) == 0 {
+		return events, nil
+	}
+	if _, err := r.store.Append(aggregate.ID(), aggregate.OriginalVersion(), events...); err != nil {
+		return nil, err
+	}
+	markCommitted(aggregate, len(events))
+	return events, nil
+}
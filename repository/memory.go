@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+)
+
+type memoryEntry[T Aggregate] struct {
+	aggregate T
+	version   int
+}
+
+// Memory is a thread-safe repository keeping copies of the aggregates state in memory.
+type Memory[T Aggregate] struct {
+	mu         sync.RWMutex
+	aggregates map[string]memoryEntry[T]
+	clone      func(T) T
+}
+
+// NewMemory will create a new empty in-memory repository, using supplied function to copy aggregates so that the
+// stored state is never shared with the callers.
+func NewMemory[T Aggregate](clone func(T) T) (*Memory[T], error) {
+	if err := assert.NotNil(clone, "clone"); err != nil {
+		return nil, err
+	}
+	return &Memory[T]{aggregates: make(map[string]memoryEntry[T]), clone: clone}, nil
+}
+
+// Load will retrieve a copy of the aggregate with supplied id.
+func (r *Memory[T]) Load(id string) (T, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := r.aggregates[id]
+	if !ok {
+		var zero T
+		return zero, notFoundError{id}
+	}
+	return r.clone(entry.aggregate), nil
+}
+
+// Save will store a copy of the supplied aggregate, checking that it was not modified since it was loaded.
+func (r *Memory[T]) Save(aggregate T) ([]domain.Event, error) {
+	if err := assert.NotNil(aggregate, "aggregate"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotEmpty(aggregate.ID(), "aggregate.ID"); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := r.aggregates[aggregate.ID()]
+	if entry.version != aggregate.OriginalVersion() {
+		return nil, concurrencyError{aggregate.ID(), aggregate.OriginalVersion(), entry.version}
+	}
+	events := aggregate.DomainEvents()
+	markCommitted(aggregate, len(events))
+	r.aggregates[aggregate.ID()] = memoryEntry[T]{r.clone(aggregate), aggregate.OriginalVersion()}
+	return events, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/eventstore"
+)
+
+// Aggregate is the interface implemented by aggregates managed by a repository. Aggregates embedding
+// domain.AggregateRoot only need to implement ID.
+type Aggregate interface {
+	// ID will return the unique identifier of the aggregate.
+	ID() string
+	// Version will return the current version of the aggregate, including uncommitted events.
+	Version() int
+	// OriginalVersion will return the version of the aggregate as it was loaded.
+	OriginalVersion() int
+	// DomainEvents will return the uncommitted events of the aggregate.
+	DomainEvents() []domain.Event
+	// MarkCommitted will clear the uncommitted events, aligning the original version to the current one.
+	MarkCommitted()
+}
+
+// partialCommitter is implemented by the aggregates able to commit only the events that were saved, keeping the ones
+// registered meanwhile. Aggregates embedding domain.AggregateRoot or domain.ConcurrentAggregateRoot implement it.
+type partialCommitter interface {
+	MarkCommittedEvents(n int)
+}
+
+// markCommitted will mark as committed the first n uncommitted events of the supplied aggregate, or all of them if
+// the aggregate is not able to commit only a part of its events.
+func markCommitted(aggregate Aggregate, n int) {
+	if committer, ok := aggregate.(partialCommitter); ok {
+		committer.MarkCommittedEvents(n)
+		return
+	}
+	aggregate.MarkCommitted()
+}
+
+// Repository is the interface implemented by aggregate repositories.
+type Repository[T Aggregate] interface {
+	// Load will retrieve the aggregate with supplied id.
+	Load(id string) (T, error)
+	// Save will persist the supplied aggregate, checking that it was not modified since it was loaded. The uncommitted
+	// events of the aggregate are returned, so they can be dispatched, and are marked as committed. Events registered
+	// while saving are kept uncommitted if the aggregate implements MarkCommittedEvents.
+	Save(aggregate T) ([]domain.Event, error)
+}
+
+type notFoundError struct {
+	id string
+}
+
+func (err notFoundError) Error() string {
+	return fmt.Sprintf("aggregate %s not found", err.id)
+}
+
+// IsNotFoundError verify if the supplied error is raised for a missing aggregate.
+func IsNotFoundError(err error) bool {
+	_, ok := err.(notFoundError)
+	return ok
+}
+
+type concurrencyError struct {
+	id              string
+	expectedVersion int
+	actualVersion   int
+}
+
+func (err concurrencyError) Error() string {
+	return fmt.Sprintf("aggregate %s is at version %d, expected version %d", err.id, err.actualVersion,
+		err.expectedVersion)
+}
+
+// IsConcurrencyError verify if the supplied error is raised because the aggregate was modified since it was loaded.
+func IsConcurrencyError(err error) bool {
+	_, ok := err.(concurrencyError)
+	return ok || eventstore.IsConcurrencyError(err)
+}
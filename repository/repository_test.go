@@ -0,0 +1,184 @@
+package repository_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/eventstore"
+	"github.com/maurofran/kit/repository"
+	. "github.com/maurofran/kit/testing"
+)
+
+type deposited struct {
+	amount int
+}
+
+func (e deposited) Type() string {
+	return "Deposited"
+}
+
+func (e deposited) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e deposited) Version() int {
+	return 1
+}
+
+type account struct {
+	domain.EventSourcedAggregateRoot
+	id      string
+	balance int
+}
+
+func newAccount(id string) *account {
+	a := &account{id: id}
+	a.On("Deposited", func(event domain.Event) {
+		a.balance += event.(deposited).amount
+	})
+	return a
+}
+
+func (a *account) ID() string {
+	return a.id
+}
+
+func (a *account) Deposit(amount int) {
+	a.Apply(deposited{amount})
+}
+
+func cloneAccount(a *account) *account {
+	c := newAccount(a.id)
+	c.balance = a.balance
+	c.RestoreVersion(a.Version())
+	return c
+}
+
+func repositories() map[string]repository.Repository[*account] {
+	memory, _ := repository.NewMemory(cloneAccount)
+	eventSourced, _ := repository.NewEventSourced(eventstore.NewMemoryStore(), newAccount)
+	return map[string]repository.Repository[*account]{"memory": memory, "eventSourced": eventSourced}
+}
+
+func TestLoad_NotFound(t *testing.T) {
+	for name, r := range repositories() {
+		_, err := r.Load("missing")
+
+		Assert(t, repository.IsNotFoundError(err), "%s: should return a not found error", name)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	for name, r := range repositories() {
+		a := newAccount("account-1")
+		a.Deposit(10)
+		a.Deposit(5)
+		events, err := r.Save(a)
+
+		Ok(t, err)
+		Equals(t, []domain.Event{deposited{10}, deposited{5}}, events)
+		Equals(t, 0, len(a.DomainEvents()))
+		Equals(t, 2, a.OriginalVersion())
+
+		loaded, err := r.Load("account-1")
+
+		Ok(t, err)
+		Assert(t, loaded != a, "%s: should return a new aggregate", name)
+		Equals(t, 15, loaded.balance)
+		Equals(t, 2, loaded.Version())
+	}
+}
+
+func TestSave_Conflict(t *testing.T) {
+	for name, r := range repositories() {
+		a := newAccount("account-1")
+		a.Deposit(10)
+		r.Save(a)
+		first, _ := r.Load("account-1")
+		second, _ := r.Load("account-1")
+		first.Deposit(1)
+		second.Deposit(2)
+		_, err := r.Save(first)
+
+		Ok(t, err)
+
+		_, err = r.Save(second)
+
+		Assert(t, repository.IsConcurrencyError(err), "%s: should return a concurrency error", name)
+		Equals(t, 1, len(second.DomainEvents()))
+		loaded, _ := r.Load("account-1")
+		Equals(t, 11, loaded.balance)
+	}
+}
+
+func TestSave_AndEventsFrom(t *testing.T) {
+	for name, r := range repositories() {
+		a := newAccount("account-1")
+		a.Deposit(10)
+		r.Save(a)
+		other := newAccount("account-1")
+		other.Deposit(5)
+		a.AndEventsFrom(&other.AggregateRoot)
+		_, err := r.Save(a)
+
+		Ok(t, err)
+		a.Deposit(1)
+		_, err = r.Save(a)
+		Ok(t, err)
+		loaded, _ := r.Load("account-1")
+		Equals(t, 3, loaded.Version())
+		Assert(t, loaded.Version() == a.Version(), "%s: unexpected version %d", name, a.Version())
+	}
+}
+
+type sharedAccount struct {
+	domain.ConcurrentAggregateRoot
+	id string
+}
+
+func (a *sharedAccount) ID() string {
+	return a.id
+}
+
+func (a *sharedAccount) LoadFromHistory(events []domain.Event) error {
+	for _, event := range events {
+		a.RegisterEvent(event)
+	}
+	a.MarkCommitted()
+	return nil
+}
+
+func TestSave_ConcurrentRegister(t *testing.T) {
+	store := eventstore.NewMemoryStore()
+	r, _ := repository.NewEventSourced(store, func(id string) *sharedAccount { return &sharedAccount{id: id} })
+	a := &sharedAccount{id: "account-1"}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				a.RegisterEvent(deposited{i*100 + j})
+			}
+		}(i)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_, err := r.Save(a)
+			Ok(t, err)
+		}
+	}()
+	wg.Wait()
+	<-done
+	_, err := r.Save(a)
+
+	Ok(t, err)
+	records, _ := store.Load("account-1", 1)
+	Equals(t, 400, len(records))
+	Equals(t, 400, a.OriginalVersion())
+	Equals(t, 0, len(a.DomainEvents()))
+}
@@ -0,0 +1,50 @@
+package saga
+
+import "time"
+
+// Context is the object through which saga handlers read and update the state of an instance.
+type Context struct {
+	state    *State
+	commands []interface{}
+}
+
+// ID will return the correlation key of the saga instance.
+func (c *Context) ID() string {
+	return c.state.ID
+}
+
+// Get will retrieve the value of supplied key from the instance data.
+func (c *Context) Get(key string) (interface{}, bool) {
+	value, ok := c.state.Data[key]
+	return value, ok
+}
+
+// Set will store the supplied value in the instance data.
+func (c *Context) Set(key string, value interface{}) {
+	if c.state.Data == nil {
+		c.state.Data = make(map[string]interface{})
+	}
+	c.state.Data[key] = value
+}
+
+// Send will emit the supplied command, sent once the handler completes successfully and the state is saved. The
+// command is discarded if the handler fails.
+func (c *Context) Send(command interface{}) {
+	c.commands = append(c.commands, command)
+}
+
+// Compensate will register the command undoing the current step, sent if the saga later fails.
+func (c *Context) Compensate(command interface{}) {
+	c.state.Compensations = append(c.state.Compensations, command)
+}
+
+// Deadline will set the instant the instance times out. A zero instant clears the deadline.
+func (c *Context) Deadline(at time.Time) {
+	c.state.Deadline = at
+}
+
+// Complete will mark the saga instance as successfully completed.
+func (c *Context) Complete() {
+	c.state.Completed = true
+	c.state.Deadline = time.Time{}
+}
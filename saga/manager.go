@@ -0,0 +1,226 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/domain"
+)
+
+// Manager is the process manager delivering events and timeouts to the instances of a saga. When a handler fails,
+// the compensating commands registered by the previous steps of the instance are sent in reverse order and the
+// instance is completed as failed, while the commands and compensations registered by the failing handler are
+// discarded.
+//
+// The commands are saved with the state of the instance before being sent, so that none is sent if the state cannot
+// be saved, and the ones whose sending failed are sent again with the following event or timeout of the instance.
+// Commands are therefore sent at least once.
+//
+// The events and timeouts of an instance are processed one at a time by a manager. Managers of different processes
+// sharing the store are guarded by the version of the state: the update of an instance changed concurrently fails with
+// a conflict error, and the event should be delivered again.
+type Manager struct {
+	saga   *Saga
+	store  Store
+	sender Sender
+	mu     sync.Mutex
+	locks  map[string]*instanceLock
+}
+
+type instanceLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewManager will create a new manager for supplied saga, storing instances in supplied store and sending commands
+// through supplied sender.
+func NewManager(saga *Saga, store Store, sender Sender) (*Manager, error) {
+	if err := assert.NotNil(saga, "saga"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(store, "store"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(sender, "sender"); err != nil {
+		return nil, err
+	}
+	return &Manager{saga: saga, store: store, sender: sender, locks: make(map[string]*instanceLock)}, nil
+}
+
+// Handle will deliver the supplied event to the saga instance matching its correlation key. Events without
+// correlation key, not handled by the saga, or correlated to missing or completed instances are ignored, unless they
+// start the saga.
+func (m *Manager) Handle(envelope *domain.Envelope) error {
+	if err := assert.NotNil(envelope, "envelope"); err != nil {
+		return err
+	}
+	eventType := envelope.Event().Type()
+	handler, ok := m.saga.handlers[eventType]
+	if !ok {
+		return nil
+	}
+	value, _ := envelope.Metadata().Get(m.saga.correlationKey)
+	id, _ := value.(string)
+	if id == "" {
+		return nil
+	}
+	defer m.lock(id)()
+	state, found, err := m.store.Load(m.saga.name, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		if !m.saga.starters[eventType] {
+			return nil
+		}
+		state = State{Saga: m.saga.name, ID: id}
+	}
+	if err := m.flush(&state); err != nil {
+		return err
+	}
+	if state.Completed {
+		return nil
+	}
+	return m.run(state, func(ctx *Context) error {
+		return handler(ctx, envelope)
+	})
+}
+
+// CheckDeadlines will deliver a timeout to the running saga instances whose deadline is not after now, returning the
+// first error occurred.
+func (m *Manager) CheckDeadlines(now time.Time) error {
+	states, err := m.store.Expired(m.saga.name, now)
+	if err != nil {
+		return err
+	}
+	var first error
+	for _, state := range states {
+		if err := m.timeout(state.ID, now); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// timeout will deliver a timeout to the instance with supplied id, if still expired once locked.
+func (m *Manager) timeout(id string, now time.Time) error {
+	defer m.lock(id)()
+	state, found, err := m.store.Load(m.saga.name, id)
+	if err != nil || !found {
+		return err
+	}
+	if err := m.flush(&state); err != nil {
+		return err
+	}
+	if state.Completed || state.Deadline.IsZero() || state.Deadline.After(now) {
+		return nil
+	}
+	state.Deadline = time.Time{}
+	return m.run(state, func(ctx *Context) error {
+		if m.saga.onTimeout == nil {
+			return fmt.Errorf("saga %s instance %s timed out", m.saga.name, ctx.ID())
+		}
+		return m.saga.onTimeout(ctx)
+	})
+}
+
+// lock will lock the instance with supplied id, returning the function unlocking it.
+func (m *Manager) lock(id string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[id]
+	if !ok {
+		l = new(instanceLock)
+		m.locks[id] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		m.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, id)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// RunDeadlines will check the expired deadlines every interval of supplied clock until the supplied context is done.
+// Errors do not stop the loop and are reported to onError, that can be nil.
+func (m *Manager) RunDeadlines(ctx context.Context, clk clock.Clock, interval time.Duration, onError func(error)) error {
+	ticker := clk.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C():
+			if err := m.CheckDeadlines(now); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// run will execute the supplied handler on a copy of the state, saving the resulting state with the emitted commands
+// before sending them. If the handler fails, its changes, commands and compensations are discarded, since its step was
+// not performed, and the instance is compensated.
+func (m *Manager) run(state State, handle func(*Context) error) error {
+	working := state.copy()
+	ctx := &Context{state: &working}
+	if err := handle(ctx); err != nil {
+		return m.compensate(state, err)
+	}
+	working.Pending = append(working.Pending, ctx.commands...)
+	if err := m.save(&working); err != nil {
+		return err
+	}
+	return m.flush(&working)
+}
+
+// compensate will complete the instance as failed, saving it with its compensations in reverse order before sending
+// them, and returning the supplied cause.
+func (m *Manager) compensate(state State, cause error) error {
+	for i := len(state.Compensations) - 1; i >= 0; i-- {
+		state.Pending = append(state.Pending, state.Compensations[i])
+	}
+	state.Compensations = nil
+	state.Completed = true
+	state.Failed = true
+	state.Deadline = time.Time{}
+	if err := m.save(&state); err != nil {
+		return err
+	}
+	if err := m.flush(&state); err != nil {
+		return err
+	}
+	return cause
+}
+
+// flush will send the pending commands of the instance, saving it without them once all were sent. If sending fails,
+// the commands are left pending and sent again by the following event or timeout of the instance.
+func (m *Manager) flush(state *State) error {
+	if len(state.Pending) == 0 {
+		return nil
+	}
+	for _, command := range state.Pending {
+		if err := m.sender.Send(command); err != nil {
+			return err
+		}
+	}
+	state.Pending = nil
+	return m.save(state)
+}
+
+// save will store the supplied state, increasing its version as the store did.
+func (m *Manager) save(state *State) error {
+	if err := m.store.Save(*state); err != nil {
+		return err
+	}
+	state.Version++
+	return nil
+}
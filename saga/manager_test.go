@@ -0,0 +1,264 @@
+package saga_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/metadata"
+	"github.com/maurofran/kit/saga"
+	. "github.com/maurofran/kit/testing"
+)
+
+type testEvent struct {
+	name string
+}
+
+func (e testEvent) Type() string {
+	return e.name
+}
+
+func (e testEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e testEvent) Version() int {
+	return 1
+}
+
+type recordingSender struct {
+	commands []interface{}
+	err      error
+}
+
+func (s *recordingSender) Send(command interface{}) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.commands = append(s.commands, command)
+	return nil
+}
+
+type conflictingStore struct {
+	*saga.MemoryStore
+}
+
+func (s conflictingStore) Save(state saga.State) error {
+	state.Version--
+	return s.MemoryStore.Save(state)
+}
+
+func anEnvelope(eventType, correlationID string) *domain.Envelope {
+	e, _ := domain.NewEnvelope(eventType+"-id", "order-1", 1, testEvent{eventType},
+		metadata.With(domain.CorrelationIDKey, correlationID))
+	return e
+}
+
+var deadline = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func anOrderSaga() *saga.Saga {
+	s, _ := saga.New("order")
+	return s.StartedBy("OrderPlaced", func(ctx *saga.Context, e *domain.Envelope) error {
+		ctx.Set("order", e.StreamID())
+		ctx.Send("ChargePayment")
+		ctx.Compensate("CancelOrder")
+		ctx.Deadline(deadline)
+		return nil
+	}).On("PaymentCharged", func(ctx *saga.Context, e *domain.Envelope) error {
+		ctx.Send("ShipOrder")
+		ctx.Compensate("RefundPayment")
+		return nil
+	}).On("OrderShipped", func(ctx *saga.Context, e *domain.Envelope) error {
+		ctx.Complete()
+		return nil
+	}).On("ShippingFailed", func(ctx *saga.Context, e *domain.Envelope) error {
+		ctx.Send("NotifyCustomer")
+		ctx.Compensate("ReturnOrder")
+		return errors.New("shipping failed")
+	}).OnTimeout(func(ctx *saga.Context) error {
+		return errors.New("payment not received")
+	})
+}
+
+func aManager() (*saga.Manager, *saga.MemoryStore, *recordingSender) {
+	store := saga.NewMemoryStore()
+	sender := new(recordingSender)
+	m, _ := saga.NewManager(anOrderSaga(), store, sender)
+	return m, store, sender
+}
+
+func TestHandle_CompletesSaga(t *testing.T) {
+	m, store, sender := aManager()
+
+	Ok(t, m.Handle(anEnvelope("OrderPlaced", "c1")))
+	Ok(t, m.Handle(anEnvelope("PaymentCharged", "c1")))
+	Ok(t, m.Handle(anEnvelope("OrderShipped", "c1")))
+
+	Equals(t, []interface{}{"ChargePayment", "ShipOrder"}, sender.commands)
+	state, ok, _ := store.Load("order", "c1")
+	Equals(t, true, ok)
+	Equals(t, true, state.Completed)
+	Equals(t, false, state.Failed)
+	Equals(t, "order-1", state.Data["order"])
+}
+
+func TestHandle_IgnoresUncorrelatedEvents(t *testing.T) {
+	m, store, sender := aManager()
+
+	Ok(t, m.Handle(anEnvelope("PaymentCharged", "c1")))
+	Ok(t, m.Handle(anEnvelope("Unknown", "c1")))
+
+	Equals(t, 0, len(sender.commands))
+	_, ok, _ := store.Load("order", "c1")
+	Equals(t, false, ok)
+}
+
+func TestHandle_CorrelatesByKey(t *testing.T) {
+	m, _, sender := aManager()
+
+	Ok(t, m.Handle(anEnvelope("OrderPlaced", "c1")))
+	Ok(t, m.Handle(anEnvelope("PaymentCharged", "c2")))
+
+	Equals(t, []interface{}{"ChargePayment"}, sender.commands)
+}
+
+func TestHandle_CompensatesOnFailure(t *testing.T) {
+	m, store, sender := aManager()
+	m.Handle(anEnvelope("OrderPlaced", "c1"))
+	m.Handle(anEnvelope("PaymentCharged", "c1"))
+	err := m.Handle(anEnvelope("ShippingFailed", "c1"))
+
+	Equals(t, errors.New("shipping failed"), err)
+	Equals(t, []interface{}{"ChargePayment", "ShipOrder", "RefundPayment", "CancelOrder"}, sender.commands)
+	state, _, _ := store.Load("order", "c1")
+	Equals(t, true, state.Completed)
+	Equals(t, true, state.Failed)
+
+	Ok(t, m.Handle(anEnvelope("OrderShipped", "c1")))
+	Equals(t, 4, len(sender.commands))
+}
+
+func TestHandle_ConflictSendsNothing(t *testing.T) {
+	store := saga.NewMemoryStore()
+	sender := new(recordingSender)
+	m, _ := saga.NewManager(anOrderSaga(), store, sender)
+	Ok(t, m.Handle(anEnvelope("OrderPlaced", "c1")))
+	m, _ = saga.NewManager(anOrderSaga(), conflictingStore{store}, sender)
+	err := m.Handle(anEnvelope("PaymentCharged", "c1"))
+
+	Assert(t, saga.IsConflictError(err), "should return a conflict error")
+	Equals(t, []interface{}{"ChargePayment"}, sender.commands)
+}
+
+func TestHandle_ResendsPendingCommands(t *testing.T) {
+	m, store, sender := aManager()
+	sender.err = errors.New("broker unavailable")
+	err := m.Handle(anEnvelope("OrderPlaced", "c1"))
+
+	Equals(t, sender.err, err)
+	state, _, _ := store.Load("order", "c1")
+	Equals(t, []interface{}{"ChargePayment"}, state.Pending)
+
+	sender.err = nil
+	Ok(t, m.Handle(anEnvelope("PaymentCharged", "c1")))
+	Equals(t, []interface{}{"ChargePayment", "ShipOrder"}, sender.commands)
+	state, _, _ = store.Load("order", "c1")
+	Equals(t, 0, len(state.Pending))
+}
+
+func TestHandle_Concurrent(t *testing.T) {
+	s, _ := saga.New("counter")
+	s.StartedBy("Incremented", func(ctx *saga.Context, e *domain.Envelope) error {
+		n, _ := ctx.Get("n")
+		count, _ := n.(int)
+		ctx.Set("n", count+1)
+		return nil
+	})
+	store := saga.NewMemoryStore()
+	m, _ := saga.NewManager(s, store, new(recordingSender))
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Ok(t, m.Handle(anEnvelope("Incremented", "c1")))
+		}()
+	}
+	wg.Wait()
+
+	state, _, _ := store.Load("counter", "c1")
+	Equals(t, 50, state.Data["n"])
+	Equals(t, 50, state.Version)
+}
+
+func TestMemoryStore_SaveConflict(t *testing.T) {
+	store := saga.NewMemoryStore()
+	Ok(t, store.Save(saga.State{Saga: "order", ID: "c1"}))
+	state, _, _ := store.Load("order", "c1")
+	Equals(t, 1, state.Version)
+
+	Ok(t, store.Save(state))
+	err := store.Save(state)
+	Assert(t, saga.IsConflictError(err), "should return a conflict error")
+	err = store.Save(saga.State{Saga: "order", ID: "c1"})
+	Assert(t, saga.IsConflictError(err), "should return a conflict error")
+}
+
+func TestCheckDeadlines(t *testing.T) {
+	m, store, sender := aManager()
+	m.Handle(anEnvelope("OrderPlaced", "c1"))
+
+	Ok(t, m.CheckDeadlines(deadline.Add(-time.Second)))
+	Equals(t, 1, len(sender.commands))
+
+	err := m.CheckDeadlines(deadline)
+
+	Equals(t, errors.New("payment not received"), err)
+	Equals(t, []interface{}{"ChargePayment", "CancelOrder"}, sender.commands)
+	state, _, _ := store.Load("order", "c1")
+	Equals(t, true, state.Failed)
+}
+
+func TestRunDeadlines(t *testing.T) {
+	m, store, _ := aManager()
+	m.Handle(anEnvelope("OrderPlaced", "c1"))
+	clk := clock.NewFake(deadline.Add(-time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	done := make(chan error)
+	go func() {
+		done <- m.RunDeadlines(ctx, clk, time.Minute, func(err error) {
+			errs <- err
+		})
+	}()
+	for clk.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clk.Advance(time.Minute)
+
+	Equals(t, errors.New("payment not received"), <-errs)
+	state, _, _ := store.Load("order", "c1")
+	Equals(t, true, state.Failed)
+
+	cancel()
+	Equals(t, context.Canceled, <-done)
+}
+
+func TestCorrelateBy(t *testing.T) {
+	s, _ := saga.New("custom")
+	started := 0
+	s.CorrelateBy("orderId").StartedBy("OrderPlaced", func(ctx *saga.Context, e *domain.Envelope) error {
+		started++
+		return nil
+	})
+	m, _ := saga.NewManager(s, saga.NewMemoryStore(), new(recordingSender))
+
+	Ok(t, m.Handle(anEnvelope("OrderPlaced", "c1")))
+	Equals(t, 0, started)
+	Ok(t, m.Handle(anEnvelope("OrderPlaced", "c1").WithMetadata(map[string]interface{}{"orderId": "o1"})))
+	Equals(t, 1, started)
+}
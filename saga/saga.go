@@ -0,0 +1,82 @@
+package saga
+
+import (
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/domain"
+)
+
+// HandlerFunc is the function reacting to an event delivered to a saga instance.
+type HandlerFunc func(*Context, *domain.Envelope) error
+
+// TimeoutFunc is the function invoked when the deadline of a saga instance expires.
+type TimeoutFunc func(*Context) error
+
+// Sender is the interface implemented by objects sending the commands emitted by sagas.
+type Sender interface {
+	// Send will send the supplied command, returning an error if it could not be sent.
+	Send(command interface{}) error
+}
+
+// SenderFunc is an adapter allowing to use ordinary functions as command senders.
+type SenderFunc func(command interface{}) error
+
+// Send will invoke the receiver function with supplied command.
+func (f SenderFunc) Send(command interface{}) error {
+	return f(command)
+}
+
+// Saga is the definition of a long running process reacting to domain events. Events are routed to saga instances by
+// a correlation key read from their metadata.
+type Saga struct {
+	name           string
+	correlationKey string
+	starters       map[string]bool
+	handlers       map[string]HandlerFunc
+	onTimeout      TimeoutFunc
+}
+
+// New will create a new saga definition with supplied name, correlating events by domain.CorrelationIDKey.
+func New(name string) (*Saga, error) {
+	if err := assert.NotEmpty(name, "name"); err != nil {
+		return nil, err
+	}
+	return &Saga{
+		name:           name,
+		correlationKey: domain.CorrelationIDKey,
+		starters:       make(map[string]bool),
+		handlers:       make(map[string]HandlerFunc),
+	}, nil
+}
+
+// Name will return the name of the saga.
+func (s *Saga) Name() string {
+	return s.name
+}
+
+// CorrelateBy will set the metadata key used to route events to saga instances, returning the receiver saga.
+func (s *Saga) CorrelateBy(key string) *Saga {
+	s.correlationKey = key
+	return s
+}
+
+// StartedBy will register the handler for the events of supplied type, that start a new saga instance when none
+// exists for their correlation key. It returns the receiver saga.
+func (s *Saga) StartedBy(eventType string, handler HandlerFunc) *Saga {
+	s.starters[eventType] = true
+	s.handlers[eventType] = handler
+	return s
+}
+
+// On will register the handler for the events of supplied type, delivered only to existing saga instances. It
+// returns the receiver saga.
+func (s *Saga) On(eventType string, handler HandlerFunc) *Saga {
+	s.handlers[eventType] = handler
+	return s
+}
+
+// OnTimeout will register the function invoked when the deadline of a saga instance expires, returning the receiver
+// saga.
+func (s *Saga) OnTimeout(handler TimeoutFunc) *Saga {
+	s.onTimeout = handler
+	return s
+}
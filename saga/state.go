@@ -0,0 +1,125 @@
+package saga
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the persisted state of a saga instance.
+type State struct {
+	// Saga is the name of the saga.
+	Saga string
+	// ID is the correlation key of the instance.
+	ID string
+	// Data is the data of the instance.
+	Data map[string]interface{}
+	// Deadline is the instant the instance times out, zero if none.
+	Deadline time.Time
+	// Compensations are the commands undoing the completed steps, in the order they were registered.
+	Compensations []interface{}
+	// Completed reports if the instance is completed, either successfully or not.
+	Completed bool
+	// Failed reports if the instance failed and was compensated.
+	Failed bool
+	// Pending are the commands emitted by the instance and not yet sent, in sending order. They are saved with the
+	// state before being sent, and sent again by the following event or timeout of the instance if sending failed.
+	Pending []interface{}
+	// Version is the number of times the state was saved, zero for a new instance. Stores reject the states whose
+	// version differs from the saved one, since they were loaded before a concurrent update.
+	Version int
+}
+
+type conflictError struct {
+	saga     string
+	id       string
+	expected int
+	actual   int
+}
+
+func (err conflictError) Error() string {
+	return fmt.Sprintf("saga %s instance %s was expected at version %d, but is at version %d", err.saga, err.id,
+		err.expected, err.actual)
+}
+
+// IsConflictError verify if the supplied error is caused by a saga state updated concurrently.
+func IsConflictError(err error) bool {
+	_, ok := err.(conflictError)
+	return ok
+}
+
+// Store is the interface implemented by saga state stores.
+type Store interface {
+	// Load will retrieve the state of the instance of supplied saga with supplied id, if any.
+	Load(saga, id string) (State, bool, error)
+	// Save will store the supplied state, increasing its version. A conflict error is returned if the version of the
+	// stored state differs from the supplied one.
+	Save(State) error
+	// Expired will retrieve the states of the running instances of supplied saga whose deadline is not after now.
+	Expired(saga string, now time.Time) ([]State, error)
+}
+
+type stateKey struct {
+	saga string
+	id   string
+}
+
+// MemoryStore is a thread-safe saga state store keeping states in memory.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	states map[stateKey]State
+}
+
+// NewMemoryStore will create a new empty in-memory saga state store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[stateKey]State)}
+}
+
+// Load will retrieve the state of the instance of supplied saga with supplied id, if any.
+func (s *MemoryStore) Load(saga, id string) (State, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	state, ok := s.states[stateKey{saga, id}]
+	return state.copy(), ok, nil
+}
+
+// Save will store the supplied state, increasing its version. A conflict error is returned if the version of the
+// stored state differs from the supplied one.
+func (s *MemoryStore) Save(state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := stateKey{state.Saga, state.ID}
+	if current := s.states[key].Version; current != state.Version {
+		return conflictError{state.Saga, state.ID, state.Version, current}
+	}
+	state = state.copy()
+	state.Version++
+	s.states[key] = state
+	return nil
+}
+
+// Expired will retrieve the states of the running instances of supplied saga whose deadline is not after now.
+func (s *MemoryStore) Expired(saga string, now time.Time) ([]State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	states := make([]State, 0)
+	for key, state := range s.states {
+		if key.saga == saga && !state.Completed && !state.Deadline.IsZero() && !state.Deadline.After(now) {
+			states = append(states, state.copy())
+		}
+	}
+	return states, nil
+}
+
+func (s State) copy() State {
+	if s.Data != nil {
+		data := make(map[string]interface{}, len(s.Data))
+		for key, value := range s.Data {
+			data[key] = value
+		}
+		s.Data = data
+	}
+	s.Compensations = append([]interface{}(nil), s.Compensations...)
+	s.Pending = append([]interface{}(nil), s.Pending...)
+	return s
+}
@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/eventstore"
+)
+
+// Loader is the object loading aggregates from their latest snapshot and the events that followed it.
+type Loader struct {
+	events    eventstore.Store
+	snapshots Store
+	policy    Policy
+	clock     clock.Clock
+}
+
+// NewLoader will create a new loader reading events and snapshots from supplied stores, taking snapshots according
+// to supplied policy. Snapshots are timestamped with the system clock.
+func NewLoader(events eventstore.Store, snapshots Store, policy Policy) (*Loader, error) {
+	if err := assert.NotNil(events, "events"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(snapshots, "snapshots"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(policy, "policy"); err != nil {
+		return nil, err
+	}
+	return &Loader{events, snapshots, policy, clock.System}, nil
+}
+
+// WithClock will set the clock used to timestamp snapshots, returning the receiver loader. A nil clock is ignored.
+func (l *Loader) WithClock(clk clock.Clock) *Loader {
+	if clk != nil {
+		l.clock = clk
+	}
+	return l
+}
+
+// Load will restore the supplied aggregate from the latest snapshot of the stream, replaying only the later events.
+func (l *Loader) Load(streamID string, aggregate Aggregate) error {
+	snapshot, ok, err := l.snapshots.Latest(streamID)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := aggregate.RestoreSnapshot(snapshot.State); err != nil {
+			return err
+		}
+		aggregate.RestoreVersion(snapshot.Version)
+	}
+	records, err := l.events.Load(streamID, snapshot.Version+1)
+	if err != nil {
+		return err
+	}
+	return aggregate.LoadFromHistory(eventstore.Events(records))
+}
+
+// Take will save a snapshot of the supplied aggregate if the policy requires it, returning true if the snapshot was
+// taken. It should be invoked after the aggregate events have been persisted.
+func (l *Loader) Take(streamID string, aggregate Aggregate) (bool, error) {
+	last, _, err := l.snapshots.Latest(streamID)
+	if err != nil {
+		return false, err
+	}
+	if !l.policy.ShouldSnapshot(last, aggregate.Version()) {
+		return false, nil
+	}
+	err = l.snapshots.Save(Snapshot{
+		StreamID: streamID,
+		Version:  aggregate.Version(),
+		State:    aggregate.Snapshot(),
+		TakenAt:  l.clock.Now(),
+	})
+	return err == nil, err
+}
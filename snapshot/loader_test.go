@@ -0,0 +1,175 @@
+package snapshot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/domain"
+	"github.com/maurofran/kit/eventstore"
+	"github.com/maurofran/kit/snapshot"
+	. "github.com/maurofran/kit/testing"
+)
+
+type incremented struct{}
+
+func (e incremented) Type() string {
+	return "Incremented"
+}
+
+func (e incremented) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e incremented) Version() int {
+	return 1
+}
+
+type counter struct {
+	domain.EventSourcedAggregateRoot
+	value    int
+	replayed int
+}
+
+func newCounter() *counter {
+	c := new(counter)
+	c.On("Incremented", func(domain.Event) {
+		c.value++
+		c.replayed++
+	})
+	return c
+}
+
+func (c *counter) Snapshot() interface{} {
+	return c.value
+}
+
+func (c *counter) RestoreSnapshot(state interface{}) error {
+	c.value = state.(int)
+	return nil
+}
+
+func aStoreWith(events int) eventstore.Store {
+	s := eventstore.NewMemoryStore()
+	for i := 0; i < events; i++ {
+		s.Append("counter-1", eventstore.AnyVersion, incremented{})
+	}
+	return s
+}
+
+func TestLoad_WithoutSnapshot(t *testing.T) {
+	l, _ := snapshot.NewLoader(aStoreWith(5), snapshot.NewMemoryStore(), snapshot.EveryEvents(3))
+	c := newCounter()
+	err := l.Load("counter-1", c)
+
+	Ok(t, err)
+	Equals(t, 5, c.value)
+	Equals(t, 5, c.replayed)
+	Equals(t, 5, c.Version())
+}
+
+func TestLoad_FromSnapshot(t *testing.T) {
+	snapshots := snapshot.NewMemoryStore()
+	snapshots.Save(snapshot.Snapshot{StreamID: "counter-1", Version: 3, State: 3})
+	l, _ := snapshot.NewLoader(aStoreWith(5), snapshots, snapshot.EveryEvents(3))
+	c := newCounter()
+	err := l.Load("counter-1", c)
+
+	Ok(t, err)
+	Equals(t, 5, c.value)
+	Equals(t, 2, c.replayed)
+	Equals(t, 5, c.Version())
+	Equals(t, 5, c.OriginalVersion())
+}
+
+func TestTake(t *testing.T) {
+	events := aStoreWith(2)
+	snapshots := snapshot.NewMemoryStore()
+	clk := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	l, _ := snapshot.NewLoader(events, snapshots, snapshot.EveryEvents(3))
+	l.WithClock(clk)
+	c := newCounter()
+	l.Load("counter-1", c)
+	taken, err := l.Take("counter-1", c)
+
+	Ok(t, err)
+	Equals(t, false, taken)
+
+	c.Apply(incremented{})
+	taken, err = l.Take("counter-1", c)
+
+	Ok(t, err)
+	Equals(t, true, taken)
+	last, ok, _ := snapshots.Latest("counter-1")
+	Equals(t, true, ok)
+	Equals(t, 3, last.Version)
+	Equals(t, 3, last.State)
+	Equals(t, clk.Now(), last.TakenAt)
+}
+
+func TestWithClock_Nil(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	snapshots := snapshot.NewMemoryStore()
+	l, _ := snapshot.NewLoader(aStoreWith(3), snapshots, snapshot.EveryEvents(3))
+	l.WithClock(clk).WithClock(nil)
+	c := newCounter()
+	l.Load("counter-1", c)
+	c.Apply(incremented{})
+	c.Apply(incremented{})
+	c.Apply(incremented{})
+	_, err := l.Take("counter-1", c)
+
+	Ok(t, err)
+	last, _, _ := snapshots.Latest("counter-1")
+	Equals(t, clk.Now(), last.TakenAt)
+}
+
+func TestMemoryStore_KeepsLatest(t *testing.T) {
+	s := snapshot.NewMemoryStore()
+	s.Save(snapshot.Snapshot{StreamID: "counter-1", Version: 5})
+	s.Save(snapshot.Snapshot{StreamID: "counter-1", Version: 3})
+	last, _, err := s.Latest("counter-1")
+
+	Ok(t, err)
+	Equals(t, 5, last.Version)
+}
+
+func TestEveryEvents(t *testing.T) {
+	p := snapshot.EveryEvents(10)
+
+	Equals(t, false, p.ShouldSnapshot(snapshot.Snapshot{}, 9))
+	Equals(t, true, p.ShouldSnapshot(snapshot.Snapshot{}, 10))
+	Equals(t, false, p.ShouldSnapshot(snapshot.Snapshot{Version: 10}, 19))
+	Equals(t, true, p.ShouldSnapshot(snapshot.Snapshot{Version: 10}, 20))
+}
+
+func TestInterval(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	p := snapshot.Interval(time.Hour, clk)
+	last := snapshot.Snapshot{Version: 1, TakenAt: clk.Now()}
+
+	Equals(t, true, p.ShouldSnapshot(snapshot.Snapshot{}, 1))
+	Equals(t, false, p.ShouldSnapshot(last, 2))
+
+	clk.Advance(time.Hour)
+
+	Equals(t, true, p.ShouldSnapshot(last, 2))
+	Equals(t, false, p.ShouldSnapshot(last, 1))
+}
+
+func TestInterval_NilClock(t *testing.T) {
+	p := snapshot.Interval(time.Hour, nil)
+
+	Equals(t, true, p.ShouldSnapshot(snapshot.Snapshot{}, 1))
+	Equals(t, false, p.ShouldSnapshot(snapshot.Snapshot{Version: 1, TakenAt: time.Now()}, 2))
+}
+
+func TestAny(t *testing.T) {
+	p := snapshot.Any(snapshot.EveryEvents(10), snapshot.PolicyFunc(func(last snapshot.Snapshot, version int) bool {
+		return version == 3
+	}))
+
+	Equals(t, true, p.ShouldSnapshot(snapshot.Snapshot{}, 3))
+	Equals(t, false, p.ShouldSnapshot(snapshot.Snapshot{}, 4))
+	Equals(t, true, p.ShouldSnapshot(snapshot.Snapshot{}, 10))
+}
@@ -0,0 +1,39 @@
+package snapshot
+
+import (
+	"sync"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// MemoryStore is a thread-safe snapshot store keeping the latest snapshot of each stream in memory.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	snapshots map[string]Snapshot
+}
+
+// NewMemoryStore will create a new empty in-memory snapshot store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{snapshots: make(map[string]Snapshot)}
+}
+
+// Save will store the supplied snapshot, unless a more recent one is already stored for the same stream.
+func (s *MemoryStore) Save(snapshot Snapshot) error {
+	if err := assert.NotEmpty(snapshot.StreamID, "streamID"); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.snapshots[snapshot.StreamID]; !ok || existing.Version <= snapshot.Version {
+		s.snapshots[snapshot.StreamID] = snapshot
+	}
+	return nil
+}
+
+// Latest will retrieve the latest snapshot of supplied stream, if any.
+func (s *MemoryStore) Latest(streamID string) (Snapshot, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshot, ok := s.snapshots[streamID]
+	return snapshot, ok, nil
+}
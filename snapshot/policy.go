@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"time"
+
+	"github.com/maurofran/kit/clock"
+)
+
+// Policy is the interface implemented by objects deciding when an aggregate should be snapshotted.
+type Policy interface {
+	// ShouldSnapshot will check if a new snapshot should be taken for an aggregate at supplied version, given the
+	// last snapshot taken. The last snapshot has a zero version if no snapshot was taken yet.
+	ShouldSnapshot(last Snapshot, version int) bool
+}
+
+// PolicyFunc is an adapter allowing to use ordinary functions as snapshot policies.
+type PolicyFunc func(last Snapshot, version int) bool
+
+// ShouldSnapshot will invoke the receiver function.
+func (f PolicyFunc) ShouldSnapshot(last Snapshot, version int) bool {
+	return f(last, version)
+}
+
+// EveryEvents will return a policy requiring a snapshot every n events.
+func EveryEvents(n int) Policy {
+	return PolicyFunc(func(last Snapshot, version int) bool {
+		return version-last.Version >= n
+	})
+}
+
+// Interval will return a policy requiring a snapshot when the last one is older than supplied interval, according to
+// supplied clock, the system one if nil, and new events were recorded since then.
+func Interval(interval time.Duration, clk clock.Clock) Policy {
+	if clk == nil {
+		clk = clock.System
+	}
+	return PolicyFunc(func(last Snapshot, version int) bool {
+		return version > last.Version && clk.Since(last.TakenAt) >= interval
+	})
+}
+
+// Any will return a policy requiring a snapshot when at least one of supplied policies requires it.
+func Any(policies ...Policy) Policy {
+	return PolicyFunc(func(last Snapshot, version int) bool {
+		for _, policy := range policies {
+			if policy.ShouldSnapshot(last, version) {
+				return true
+			}
+		}
+		return false
+	})
+}
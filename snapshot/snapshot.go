@@ -0,0 +1,42 @@
+package snapshot
+
+import (
+	"time"
+
+	"github.com/maurofran/kit/domain"
+)
+
+// Snapshot is the state of an aggregate at a given version of its stream.
+type Snapshot struct {
+	// StreamID is the identifier of the stream of the aggregate.
+	StreamID string
+	// Version is the version of the stream the state refers to.
+	Version int
+	// State is the aggregate state. It must not be shared with the aggregate, that could mutate it.
+	State interface{}
+	// TakenAt is the instant the snapshot was taken.
+	TakenAt time.Time
+}
+
+// Store is the interface implemented by snapshot stores.
+type Store interface {
+	// Save will store the supplied snapshot.
+	Save(Snapshot) error
+	// Latest will retrieve the latest snapshot of supplied stream, if any.
+	Latest(streamID string) (Snapshot, bool, error)
+}
+
+// Aggregate is the interface implemented by event-sourced aggregates that can be snapshotted. Aggregates embedding
+// domain.EventSourcedAggregateRoot only need to implement Snapshot and RestoreSnapshot.
+type Aggregate interface {
+	// LoadFromHistory will replay the supplied events on the aggregate.
+	LoadFromHistory([]domain.Event) error
+	// RestoreVersion will set the aggregate version after its state was restored.
+	RestoreVersion(int)
+	// Version will return the current version of the aggregate.
+	Version() int
+	// Snapshot will return a copy of the aggregate state.
+	Snapshot() interface{}
+	// RestoreSnapshot will replace the aggregate state with supplied one.
+	RestoreSnapshot(interface{}) error
+}
@@ -0,0 +1,147 @@
+package specification
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/maurofran/kit/assert"
+)
+
+// Result is the outcome of the evaluation of a specification against a candidate.
+type Result struct {
+	// Name is the name of the evaluated specification.
+	Name string
+	// Satisfied reports if the candidate satisfies the specification.
+	Satisfied bool
+	// Reason explains why the candidate does not satisfy the specification, empty if it does.
+	Reason string
+	// Causes are the results of the evaluated sub-specifications, if any.
+	Causes []Result
+}
+
+// Explain will render a human readable tree of the result, marking each evaluated rule as passed or failed.
+func (r Result) Explain() string {
+	var b strings.Builder
+	r.explain(&b, 0)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (r Result) explain(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	if r.Satisfied {
+		b.WriteString("[pass] ")
+		b.WriteString(r.Name)
+	} else {
+		b.WriteString("[fail] ")
+		b.WriteString(r.Name)
+		if len(r.Causes) == 0 && r.Reason != "" {
+			b.WriteString(": ")
+			b.WriteString(r.Reason)
+		}
+	}
+	b.WriteString("\n")
+	for _, cause := range r.Causes {
+		cause.explain(b, depth+1)
+	}
+}
+
+// Specification is the interface implemented by business rules evaluated against candidates of type T.
+type Specification[T any] interface {
+	// Check will evaluate the specification against the supplied candidate.
+	Check(candidate T) Result
+}
+
+// Func is an adapter allowing to use ordinary functions as specifications.
+type Func[T any] func(candidate T) Result
+
+// Check will invoke the receiver function with supplied candidate.
+func (f Func[T]) Check(candidate T) Result {
+	return f(candidate)
+}
+
+// New will create a new specification with supplied name, satisfied by candidates for which predicate returns true.
+// The supplied reason explains why unsatisfying candidates are rejected.
+func New[T any](name string, predicate func(T) bool, reason string) Specification[T] {
+	return Func[T](func(candidate T) Result {
+		if predicate(candidate) {
+			return Result{Name: name, Satisfied: true}
+		}
+		return Result{Name: name, Reason: reason}
+	})
+}
+
+// And will create a specification satisfied when all the supplied specifications are satisfied.
+func And[T any](specs ...Specification[T]) Specification[T] {
+	return composite("all of", specs, func(satisfied int) bool {
+		return satisfied == len(specs)
+	})
+}
+
+// Or will create a specification satisfied when at least one of the supplied specifications is satisfied.
+func Or[T any](specs ...Specification[T]) Specification[T] {
+	return composite("any of", specs, func(satisfied int) bool {
+		return satisfied > 0
+	})
+}
+
+// Not will create a specification satisfied when the supplied specification is not.
+func Not[T any](spec Specification[T]) Specification[T] {
+	return Func[T](func(candidate T) Result {
+		cause := spec.Check(candidate)
+		result := Result{Name: "not " + cause.Name, Satisfied: !cause.Satisfied}
+		if !result.Satisfied {
+			result.Reason = fmt.Sprintf("%s is satisfied", cause.Name)
+		}
+		return result
+	})
+}
+
+// Named will give the supplied name to a specification, typically a composite one.
+func Named[T any](name string, spec Specification[T]) Specification[T] {
+	return Func[T](func(candidate T) Result {
+		result := spec.Check(candidate)
+		result.Name = name
+		return result
+	})
+}
+
+func composite[T any](name string, specs []Specification[T], satisfied func(int) bool) Specification[T] {
+	return Func[T](func(candidate T) Result {
+		result := Result{Name: name, Causes: make([]Result, len(specs))}
+		count := 0
+		var reasons []string
+		for i, spec := range specs {
+			result.Causes[i] = spec.Check(candidate)
+			if result.Causes[i].Satisfied {
+				count++
+			} else {
+				reasons = append(reasons, result.Causes[i].Reason)
+			}
+		}
+		result.Satisfied = satisfied(count)
+		if !result.Satisfied {
+			result.Reason = strings.Join(reasons, "; ")
+		}
+		return result
+	})
+}
+
+// IsSatisfiedBy will check if the supplied candidate satisfies the supplied specification.
+func IsSatisfiedBy[T any](spec Specification[T], candidate T) bool {
+	return spec.Check(candidate).Satisfied
+}
+
+// Argument will verify that the supplied argument satisfies the specification, returning an argument error in the
+// format '<argName> does not satisfy <name>: <reason>' if it does not.
+func Argument[T any](spec Specification[T], value T, argName string) error {
+	result := spec.Check(value)
+	return assert.Condition(result.Satisfied, fmt.Sprintf("%s does not satisfy %s: %s", argName, result.Name,
+		result.Reason))
+}
+
+// State will verify that the supplied state satisfies the specification, returning a state error in the format
+// '<name> is not satisfied: <reason>' if it does not.
+func State[T any](spec Specification[T], state T) error {
+	result := spec.Check(state)
+	return assert.State(result.Satisfied, fmt.Sprintf("%s is not satisfied: %s", result.Name, result.Reason))
+}
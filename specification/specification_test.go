@@ -0,0 +1,83 @@
+package specification_test
+
+import (
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/specification"
+	. "github.com/maurofran/kit/testing"
+)
+
+type customer struct {
+	age       int
+	suspended bool
+	vip       bool
+}
+
+var (
+	adult = specification.New("adult", func(c customer) bool {
+		return c.age >= 18
+	}, "customer is under 18")
+	suspended = specification.New("suspended", func(c customer) bool {
+		return c.suspended
+	}, "customer is not suspended")
+	vip = specification.New("vip", func(c customer) bool {
+		return c.vip
+	}, "customer is not vip")
+	eligible = specification.Named("eligible", specification.And(adult, specification.Not(suspended)))
+)
+
+func TestNew(t *testing.T) {
+	Equals(t, true, specification.IsSatisfiedBy(adult, customer{age: 18}))
+	Equals(t, specification.Result{Name: "adult", Reason: "customer is under 18"}, adult.Check(customer{age: 17}))
+}
+
+func TestAnd(t *testing.T) {
+	Equals(t, true, specification.IsSatisfiedBy(eligible, customer{age: 30}))
+	Equals(t, false, specification.IsSatisfiedBy(eligible, customer{age: 30, suspended: true}))
+	result := eligible.Check(customer{age: 17, suspended: true})
+
+	Equals(t, false, result.Satisfied)
+	Equals(t, "customer is under 18; suspended is satisfied", result.Reason)
+}
+
+func TestOr(t *testing.T) {
+	s := specification.Or(adult, vip)
+
+	Equals(t, true, specification.IsSatisfiedBy(s, customer{age: 17, vip: true}))
+	Equals(t, true, specification.IsSatisfiedBy(s, customer{age: 18}))
+	Equals(t, false, specification.IsSatisfiedBy(s, customer{age: 17}))
+}
+
+func TestNot(t *testing.T) {
+	s := specification.Not(adult)
+
+	Equals(t, true, specification.IsSatisfiedBy(s, customer{age: 17}))
+	Equals(t, "not adult", s.Check(customer{age: 18}).Name)
+}
+
+func TestExplain(t *testing.T) {
+	s := specification.Or(eligible, vip)
+	explanation := s.Check(customer{age: 17}).Explain()
+
+	Equals(t, "[fail] any of\n"+
+		"  [fail] eligible\n"+
+		"    [fail] adult: customer is under 18\n"+
+		"    [pass] not suspended\n"+
+		"  [fail] vip: customer is not vip", explanation)
+}
+
+func TestArgument(t *testing.T) {
+	Ok(t, specification.Argument(eligible, customer{age: 30}, "customer"))
+	err := specification.Argument(eligible, customer{age: 17}, "customer")
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+	Equals(t, "customer does not satisfy eligible: customer is under 18", err.Error())
+}
+
+func TestState(t *testing.T) {
+	Ok(t, specification.State(eligible, customer{age: 30}))
+	err := specification.State(eligible, customer{age: 30, suspended: true})
+
+	Assert(t, assert.IsStateError(err), "should return a state error")
+}
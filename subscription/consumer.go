@@ -0,0 +1,222 @@
+package subscription
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/eventstore"
+)
+
+// HandlerFunc is the function processing an event consumed by a group.
+type HandlerFunc func(eventstore.Record) error
+
+// Consumer is a member of a consumer group. The events of the store are split in partitions by stream, and each
+// partition is processed by a single member of the group at a time. Each event is delivered at least once to the
+// group: the checkpoint of a partition is saved after each handled event.
+type Consumer struct {
+	mu          sync.Mutex
+	group       string
+	id          string
+	partitions  int
+	store       eventstore.Store
+	coordinator Coordinator
+	handler     HandlerFunc
+	ttl         time.Duration
+	owned       map[int]bool
+}
+
+// NewConsumer will create a new consumer with supplied id, member of the group consuming the events of the store
+// split in supplied number of partitions. Memberships are renewed through the coordinator on each poll and leases
+// before each handled event, and expire if not renewed within supplied time to live.
+func NewConsumer(group, id string, partitions int, store eventstore.Store, coordinator Coordinator,
+	handler HandlerFunc, ttl time.Duration) (*Consumer, error) {
+	if err := assert.NotEmpty(group, "group"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotEmpty(id, "id"); err != nil {
+		return nil, err
+	}
+	if err := assert.IntMin(partitions, 1, "partitions"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(store, "store"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(coordinator, "coordinator"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(handler, "handler"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(ttl > 0, "ttl must be positive"); err != nil {
+		return nil, err
+	}
+	return &Consumer{
+		group:       group,
+		id:          id,
+		partitions:  partitions,
+		store:       store,
+		coordinator: coordinator,
+		handler:     handler,
+		ttl:         ttl,
+		owned:       make(map[int]bool),
+	}, nil
+}
+
+// ID will return the identifier of the consumer.
+func (c *Consumer) ID() string {
+	return c.id
+}
+
+// Partitions will return the partitions currently leased to the consumer, sorted.
+func (c *Consumer) Partitions() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ownedPartitions()
+}
+
+// Poll will renew the membership of the consumer, rebalance the partitions among the live members of the group and
+// deliver to the handler the events appended to the leased partitions after their checkpoint.
+func (c *Consumer) Poll(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.rebalance(); err != nil {
+		return err
+	}
+	for _, partition := range c.ownedPartitions() {
+		if err := c.consume(ctx, partition); err != nil {
+			if IsLeaseError(err) {
+				delete(c.owned, partition)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Run will poll the events every interval until the supplied context is done or an error occurs. The consumer leaves
+// its group when returning.
+func (c *Consumer) Run(ctx context.Context, interval time.Duration) error {
+	if err := assert.Condition(interval > 0, "interval must be positive"); err != nil {
+		return err
+	}
+	defer c.Close()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Poll(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close will remove the consumer from its group, releasing its partitions to the other members.
+func (c *Consumer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.owned = make(map[int]bool)
+	return c.coordinator.Leave(c.group, c.id)
+}
+
+func (c *Consumer) ownedPartitions() []int {
+	partitions := make([]int, 0, len(c.owned))
+	for partition := range c.owned {
+		partitions = append(partitions, partition)
+	}
+	sort.Ints(partitions)
+	return partitions
+}
+
+// rebalance will release the partitions no more assigned to the consumer, leasing the assigned ones. Partitions still
+// leased to other members are acquired on a following poll, once released or expired.
+func (c *Consumer) rebalance() error {
+	members, err := c.coordinator.Join(c.group, c.id, c.ttl)
+	if err != nil {
+		return err
+	}
+	assigned := assignment(members, c.id, c.partitions)
+	for partition := range c.owned {
+		if assigned[partition] {
+			continue
+		}
+		if err := c.coordinator.Release(c.group, partition, c.id); err != nil {
+			return err
+		}
+		delete(c.owned, partition)
+	}
+	for partition := range assigned {
+		ok, err := c.coordinator.Acquire(c.group, partition, c.id, c.ttl)
+		if err != nil {
+			return err
+		}
+		if ok {
+			c.owned[partition] = true
+		} else {
+			delete(c.owned, partition)
+		}
+	}
+	return nil
+}
+
+// renew will renew the lease of the partition before handling an event, so that a slow partition is not taken over
+// by another member while being consumed. A lease error is returned if the lease was lost.
+func (c *Consumer) renew(partition int) error {
+	ok, err := c.coordinator.Acquire(c.group, partition, c.id, c.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return leaseError{group: c.group, partition: partition, consumer: c.id}
+	}
+	return nil
+}
+
+// consume will deliver the events of the partition appended after its checkpoint, renewing the lease before each
+// event and stopping as soon as the lease is lost.
+func (c *Consumer) consume(ctx context.Context, partition int) error {
+	checkpoint, err := c.coordinator.Checkpoint(c.group, partition)
+	if err != nil {
+		return err
+	}
+	it, err := eventstore.Stream(ctx, c.store, eventstore.Filter{From: checkpoint + 1})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	last, saved := checkpoint, checkpoint
+	for it.Next() {
+		record := it.Record()
+		last = record.Position
+		if Partition(record.StreamID, c.partitions) != partition {
+			continue
+		}
+		if err := c.renew(partition); err != nil {
+			return err
+		}
+		if err := c.handler(record); err != nil {
+			return fmt.Errorf("group %s failed at position %d of partition %d: %w", c.group, record.Position, partition,
+				err)
+		}
+		if err := c.coordinator.SaveCheckpoint(c.group, partition, c.id, record.Position); err != nil {
+			return err
+		}
+		saved = record.Position
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if last > saved {
+		return c.coordinator.SaveCheckpoint(c.group, partition, c.id, last)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package subscription_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/eventstore"
+	"github.com/maurofran/kit/subscription"
+	. "github.com/maurofran/kit/testing"
+)
+
+type testEvent struct {
+	name string
+}
+
+func (e testEvent) Type() string {
+	return e.name
+}
+
+func (e testEvent) OccurredOn() time.Time {
+	return time.Time{}
+}
+
+func (e testEvent) Version() int {
+	return 1
+}
+
+type recorder struct {
+	mu        sync.Mutex
+	positions map[int64]int
+	fail      error
+}
+
+func (r *recorder) handle(record eventstore.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fail != nil {
+		return r.fail
+	}
+	if r.positions == nil {
+		r.positions = make(map[int64]int)
+	}
+	r.positions[record.Position]++
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.positions)
+}
+
+func appendEvents(s eventstore.Store, streams int) {
+	for i := 0; i < streams; i++ {
+		s.Append(fmt.Sprintf("stream-%d", i), eventstore.AnyVersion, testEvent{"Created"})
+	}
+}
+
+func aConsumer(t *testing.T, id string, s eventstore.Store, c subscription.Coordinator,
+	r *recorder) *subscription.Consumer {
+	consumer, err := subscription.NewConsumer("group", id, 4, s, c, r.handle, 10*time.Second)
+	Ok(t, err)
+	return consumer
+}
+
+func TestPartition(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		streamID := fmt.Sprintf("stream-%d", i)
+		partition := subscription.Partition(streamID, 4)
+
+		Assert(t, partition >= 0 && partition < 4, "unexpected partition %d", partition)
+		Equals(t, partition, subscription.Partition(streamID, 4))
+	}
+}
+
+func TestConsumer_Rebalance(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c, _ := subscription.NewMemoryCoordinator(clk)
+	s := eventstore.NewMemoryStore()
+	a := aConsumer(t, "a", s, c, new(recorder))
+	b := aConsumer(t, "b", s, c, new(recorder))
+	ctx := context.Background()
+
+	Ok(t, a.Poll(ctx))
+	Equals(t, []int{0, 1, 2, 3}, a.Partitions())
+	Ok(t, b.Poll(ctx))
+	Equals(t, []int{}, b.Partitions())
+	Ok(t, a.Poll(ctx))
+	Ok(t, b.Poll(ctx))
+	Equals(t, []int{0, 2}, a.Partitions())
+	Equals(t, []int{1, 3}, b.Partitions())
+
+	clk.Advance(11 * time.Second)
+	Ok(t, b.Poll(ctx))
+	Equals(t, []int{0, 1, 2, 3}, b.Partitions())
+}
+
+func TestConsumer_Delivery(t *testing.T) {
+	c, _ := subscription.NewMemoryCoordinator(clock.System)
+	s := eventstore.NewMemoryStore()
+	appendEvents(s, 10)
+	ra, rb := new(recorder), new(recorder)
+	a := aConsumer(t, "a", s, c, ra)
+	b := aConsumer(t, "b", s, c, rb)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		Ok(t, a.Poll(ctx))
+		Ok(t, b.Poll(ctx))
+	}
+	appendEvents(s, 10)
+	Ok(t, a.Poll(ctx))
+	Ok(t, b.Poll(ctx))
+
+	Equals(t, 20, ra.count()+rb.count())
+	for position := range rb.positions {
+		Equals(t, 0, ra.positions[position])
+	}
+	for _, r := range []*recorder{ra, rb} {
+		for position, n := range r.positions {
+			Assert(t, n == 1, "position %d delivered %d times", position, n)
+		}
+	}
+}
+
+func TestConsumer_Checkpoint(t *testing.T) {
+	c, _ := subscription.NewMemoryCoordinator(clock.System)
+	s := eventstore.NewMemoryStore()
+	appendEvents(s, 10)
+	a := aConsumer(t, "a", s, c, new(recorder))
+	Ok(t, a.Poll(context.Background()))
+	Ok(t, a.Close())
+	appendEvents(s, 2)
+	r := new(recorder)
+	Ok(t, aConsumer(t, "c", s, c, r).Poll(context.Background()))
+
+	Equals(t, map[int64]int{11: 1, 12: 1}, r.positions)
+}
+
+func TestConsumer_HandlerFailure(t *testing.T) {
+	c, _ := subscription.NewMemoryCoordinator(clock.System)
+	s := eventstore.NewMemoryStore()
+	appendEvents(s, 10)
+	r := &recorder{fail: errors.New("boom")}
+	a := aConsumer(t, "a", s, c, r)
+
+	err := a.Poll(context.Background())
+	Assert(t, errors.Is(err, r.fail), "unexpected error %v", err)
+	r.fail = nil
+	Ok(t, a.Poll(context.Background()))
+	Equals(t, 10, r.count())
+}
+
+func TestConsumer_LeaseRenewal(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c, _ := subscription.NewMemoryCoordinator(clk)
+	s := eventstore.NewMemoryStore()
+	for i := 0; i < 3; i++ {
+		s.Append("stream-0", eventstore.AnyVersion, testEvent{"Deposited"})
+	}
+	partition := subscription.Partition("stream-0", 4)
+	var stolen []bool
+	handler := func(eventstore.Record) error {
+		clk.Advance(6 * time.Second)
+		ok, err := c.Acquire("group", partition, "b", 10*time.Second)
+		stolen = append(stolen, ok)
+		return err
+	}
+	a, _ := subscription.NewConsumer("group", "a", 4, s, c, handler, 10*time.Second)
+
+	Ok(t, a.Poll(context.Background()))
+	Equals(t, []bool{false, false, false}, stolen)
+	checkpoint, _ := c.Checkpoint("group", partition)
+	Equals(t, int64(3), checkpoint)
+}
+
+func TestConsumer_LeaseLost(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c, _ := subscription.NewMemoryCoordinator(clk)
+	s := eventstore.NewMemoryStore()
+	for i := 0; i < 3; i++ {
+		s.Append("stream-0", eventstore.AnyVersion, testEvent{"Deposited"})
+	}
+	partition := subscription.Partition("stream-0", 4)
+	handled := 0
+	handler := func(eventstore.Record) error {
+		handled++
+		clk.Advance(11 * time.Second)
+		c.Acquire("group", partition, "b", 10*time.Second)
+		return nil
+	}
+	a, _ := subscription.NewConsumer("group", "a", 4, s, c, handler, 10*time.Second)
+
+	Ok(t, a.Poll(context.Background()))
+	Equals(t, 1, handled)
+	for _, owned := range a.Partitions() {
+		Assert(t, owned != partition, "should drop the lost partition")
+	}
+}
+
+func TestMemoryCoordinator_SaveCheckpointWithoutLease(t *testing.T) {
+	c, _ := subscription.NewMemoryCoordinator(clock.System)
+	ok, _ := c.Acquire("group", 0, "a", time.Minute)
+	Assert(t, ok, "should acquire the lease")
+	ok, _ = c.Acquire("group", 0, "b", time.Minute)
+	Assert(t, !ok, "should not acquire a leased partition")
+
+	err := c.SaveCheckpoint("group", 0, "b", 1)
+	Assert(t, subscription.IsLeaseError(err), "should return a lease error")
+	Ok(t, c.SaveCheckpoint("group", 0, "a", 1))
+}
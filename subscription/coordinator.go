@@ -0,0 +1,67 @@
+package subscription
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// Coordinator is the interface implemented by the objects coordinating the consumers of the groups. Consumers join
+// their group periodically as heartbeat, and must lease a partition before processing its events. Memberships and
+// leases not renewed before their time to live expires are dropped, so that the partitions of a lost consumer are
+// rebalanced to the other members of its group.
+type Coordinator interface {
+	// Join will register or renew the membership of the consumer in the group for supplied time to live, returning
+	// the identifiers of the live members of the group, sorted.
+	Join(group, consumer string, ttl time.Duration) ([]string, error)
+	// Leave will remove the consumer from the group, releasing all its leases.
+	Leave(group, consumer string) error
+	// Acquire will lease, or renew the lease of, the partition of the group to the consumer for supplied time to live.
+	// False is returned if the partition is leased to another consumer.
+	Acquire(group string, partition int, consumer string, ttl time.Duration) (bool, error)
+	// Release will release the lease of the partition of the group, if owned by the consumer.
+	Release(group string, partition int, consumer string) error
+	// Checkpoint will return the position of the last event processed by the group in the partition.
+	Checkpoint(group string, partition int) (int64, error)
+	// SaveCheckpoint will save the position of the last event processed by the group in the partition. The consumer
+	// must own the lease of the partition.
+	SaveCheckpoint(group string, partition int, consumer string, position int64) error
+}
+
+type leaseError struct {
+	group     string
+	partition int
+	consumer  string
+}
+
+func (err leaseError) Error() string {
+	return fmt.Sprintf("partition %d of group %s is not leased to %s", err.partition, err.group, err.consumer)
+}
+
+// IsLeaseError verify if the supplied error is caused by a consumer not owning the lease of a partition.
+func IsLeaseError(err error) bool {
+	_, ok := err.(leaseError)
+	return ok
+}
+
+// Partition will return the partition of the stream with supplied id, among supplied number of partitions. The events
+// of a stream always belong to the same partition, so that they are processed in order.
+func Partition(streamID string, partitions int) int {
+	h := fnv.New32a()
+	h.Write([]byte(streamID))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// assignment will return the partitions assigned to the consumer among the sorted members of the group.
+func assignment(members []string, consumer string, partitions int) map[int]bool {
+	assigned := make(map[int]bool)
+	for i, member := range members {
+		if member != consumer {
+			continue
+		}
+		for p := i; p < partitions; p += len(members) {
+			assigned[p] = true
+		}
+	}
+	return assigned
+}
@@ -0,0 +1,142 @@
+package subscription
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+)
+
+type partitionKey struct {
+	group     string
+	partition int
+}
+
+type lease struct {
+	consumer string
+	expiry   time.Time
+}
+
+// MemoryCoordinator is a thread-safe coordinator keeping memberships, leases and checkpoints in memory, suitable for
+// tests and for consumers living in the same process. Checkpoints are lost when the process exits: the SQL coordinator
+// persists them.
+type MemoryCoordinator struct {
+	mu          sync.Mutex
+	clock       clock.Clock
+	members     map[string]map[string]time.Time
+	leases      map[partitionKey]lease
+	checkpoints map[partitionKey]int64
+}
+
+// NewMemoryCoordinator will create a new empty in-memory coordinator, expiring memberships and leases according to
+// supplied clock.
+func NewMemoryCoordinator(clk clock.Clock) (*MemoryCoordinator, error) {
+	if err := assert.NotNil(clk, "clk"); err != nil {
+		return nil, err
+	}
+	return &MemoryCoordinator{
+		clock:       clk,
+		members:     make(map[string]map[string]time.Time),
+		leases:      make(map[partitionKey]lease),
+		checkpoints: make(map[partitionKey]int64),
+	}, nil
+}
+
+// Join will register or renew the membership of the consumer in the group for supplied time to live, returning the
+// identifiers of the live members of the group, sorted.
+func (c *MemoryCoordinator) Join(group, consumer string, ttl time.Duration) ([]string, error) {
+	if err := assert.NotEmpty(group, "group"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotEmpty(consumer, "consumer"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(ttl > 0, "ttl must be positive"); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	members, ok := c.members[group]
+	if !ok {
+		members = make(map[string]time.Time)
+		c.members[group] = members
+	}
+	members[consumer] = now.Add(ttl)
+	live := make([]string, 0, len(members))
+	for member, expiry := range members {
+		if !now.Before(expiry) {
+			delete(members, member)
+			continue
+		}
+		live = append(live, member)
+	}
+	sort.Strings(live)
+	return live, nil
+}
+
+// Leave will remove the consumer from the group, releasing all its leases.
+func (c *MemoryCoordinator) Leave(group, consumer string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.members[group], consumer)
+	for key, l := range c.leases {
+		if key.group == group && l.consumer == consumer {
+			delete(c.leases, key)
+		}
+	}
+	return nil
+}
+
+// Acquire will lease, or renew the lease of, the partition of the group to the consumer for supplied time to live.
+// False is returned if the partition is leased to another consumer.
+func (c *MemoryCoordinator) Acquire(group string, partition int, consumer string, ttl time.Duration) (bool, error) {
+	if err := assert.NotEmpty(consumer, "consumer"); err != nil {
+		return false, err
+	}
+	if err := assert.Condition(ttl > 0, "ttl must be positive"); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	key := partitionKey{group, partition}
+	if l, ok := c.leases[key]; ok && l.consumer != consumer && now.Before(l.expiry) {
+		return false, nil
+	}
+	c.leases[key] = lease{consumer, now.Add(ttl)}
+	return true, nil
+}
+
+// Release will release the lease of the partition of the group, if owned by the consumer.
+func (c *MemoryCoordinator) Release(group string, partition int, consumer string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := partitionKey{group, partition}
+	if l, ok := c.leases[key]; ok && l.consumer == consumer {
+		delete(c.leases, key)
+	}
+	return nil
+}
+
+// Checkpoint will return the position of the last event processed by the group in the partition.
+func (c *MemoryCoordinator) Checkpoint(group string, partition int) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checkpoints[partitionKey{group, partition}], nil
+}
+
+// SaveCheckpoint will save the position of the last event processed by the group in the partition. The consumer must
+// own the lease of the partition.
+func (c *MemoryCoordinator) SaveCheckpoint(group string, partition int, consumer string, position int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := partitionKey{group, partition}
+	if l, ok := c.leases[key]; !ok || l.consumer != consumer || !c.clock.Now().Before(l.expiry) {
+		return leaseError{group, partition, consumer}
+	}
+	c.checkpoints[key] = position
+	return nil
+}
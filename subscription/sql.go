@@ -0,0 +1,276 @@
+package subscription
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/internal/sqlutil"
+)
+
+// Dialect is the set of database specific features used by the SQL coordinator.
+//
+// The schema of the coordinator is made of three tables:
+//
+//   - sub_members, holding the expiry of the membership of each consumer of a group;
+//   - sub_leases, holding the consumer owning each partition of a group and the expiry of its lease;
+//   - sub_checkpoints, holding the position of the last event processed by a group in each partition.
+//
+// Expiries are stored in Unix nanoseconds of the coordinator clock, so the clocks of the processes sharing the
+// database must be kept synchronized.
+type Dialect = sqlutil.Dialect
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS sub_members (
+		group_name  VARCHAR(255) NOT NULL,
+		consumer_id VARCHAR(255) NOT NULL,
+		expiry      BIGINT       NOT NULL,
+		PRIMARY KEY (group_name, consumer_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS sub_leases (
+		group_name   VARCHAR(255) NOT NULL,
+		partition_id INTEGER      NOT NULL,
+		consumer_id  VARCHAR(255) NOT NULL,
+		expiry       BIGINT       NOT NULL,
+		PRIMARY KEY (group_name, partition_id)
+	)`,
+	`CREATE TABLE IF NOT EXISTS sub_checkpoints (
+		group_name   VARCHAR(255) NOT NULL,
+		partition_id INTEGER      NOT NULL,
+		position     BIGINT       NOT NULL,
+		PRIMARY KEY (group_name, partition_id)
+	)`,
+}
+
+// SQLite is the dialect of SQLite databases.
+var SQLite = Dialect{
+	Schema:      schema,
+	Placeholder: sqlutil.QuestionMark,
+}
+
+// PostgreSQL is the dialect of PostgreSQL databases.
+var PostgreSQL = Dialect{
+	Schema:      schema,
+	Placeholder: sqlutil.Numbered,
+}
+
+// SQLCoordinator is a coordinator persisting memberships, leases and checkpoints in a relational database through
+// database/sql, so that the consumers of a group can live in different processes and the checkpoints survive their
+// restarts.
+type SQLCoordinator struct {
+	db      *sql.DB
+	dialect Dialect
+	clock   clock.Clock
+}
+
+// NewSQLCoordinator will create a new coordinator persisting its state in supplied database with supplied dialect,
+// expiring memberships and leases according to supplied clock.
+func NewSQLCoordinator(db *sql.DB, dialect Dialect, clk clock.Clock) (*SQLCoordinator, error) {
+	if err := assert.NotNil(db, "db"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(dialect.Placeholder, "dialect.Placeholder"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotNil(clk, "clk"); err != nil {
+		return nil, err
+	}
+	return &SQLCoordinator{db: db, dialect: dialect, clock: clk}, nil
+}
+
+// CreateSchema will create the tables of the coordinator, if not existing.
+func (c *SQLCoordinator) CreateSchema() error {
+	return c.dialect.CreateSchema(c.db)
+}
+
+// Join will register or renew the membership of the consumer in the group for supplied time to live, returning the
+// identifiers of the live members of the group, sorted.
+func (c *SQLCoordinator) Join(group, consumer string, ttl time.Duration) ([]string, error) {
+	if err := assert.NotEmpty(group, "group"); err != nil {
+		return nil, err
+	}
+	if err := assert.NotEmpty(consumer, "consumer"); err != nil {
+		return nil, err
+	}
+	if err := assert.Condition(ttl > 0, "ttl must be positive"); err != nil {
+		return nil, err
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	members, err := c.join(tx, group, consumer, ttl)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return members, tx.Commit()
+}
+
+func (c *SQLCoordinator) join(tx *sql.Tx, group, consumer string, ttl time.Duration) ([]string, error) {
+	now := c.clock.Now()
+	err := c.upsert(tx, "UPDATE sub_members SET expiry = ? WHERE group_name = ? AND consumer_id = ?",
+		"INSERT INTO sub_members (group_name, consumer_id, expiry) VALUES (?, ?, ?)",
+		[]interface{}{now.Add(ttl).UnixNano(), group, consumer},
+		[]interface{}{group, consumer, now.Add(ttl).UnixNano()})
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(c.dialect.Bind("DELETE FROM sub_members WHERE group_name = ? AND expiry <= ?"), group, now.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(c.dialect.Bind("SELECT consumer_id FROM sub_members WHERE group_name = ?"), group)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := make([]string, 0)
+	for rows.Next() {
+		var member string
+		if err := rows.Scan(&member); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	sort.Strings(members)
+	return members, rows.Err()
+}
+
+// Leave will remove the consumer from the group, releasing all its leases.
+func (c *SQLCoordinator) Leave(group, consumer string) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(c.dialect.Bind("DELETE FROM sub_members WHERE group_name = ? AND consumer_id = ?"), group, consumer)
+	if err == nil {
+		_, err = tx.Exec(c.dialect.Bind("DELETE FROM sub_leases WHERE group_name = ? AND consumer_id = ?"), group, consumer)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Acquire will lease, or renew the lease of, the partition of the group to the consumer for supplied time to live.
+// False is returned if the partition is leased to another consumer.
+func (c *SQLCoordinator) Acquire(group string, partition int, consumer string, ttl time.Duration) (bool, error) {
+	if err := assert.NotEmpty(consumer, "consumer"); err != nil {
+		return false, err
+	}
+	if err := assert.Condition(ttl > 0, "ttl must be positive"); err != nil {
+		return false, err
+	}
+	tx, err := c.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	ok, err := c.acquire(tx, group, partition, consumer, ttl)
+	if err != nil {
+		tx.Rollback()
+		return c.contended(group, partition, consumer, err)
+	}
+	if !ok {
+		return false, tx.Rollback()
+	}
+	return true, tx.Commit()
+}
+
+func (c *SQLCoordinator) acquire(tx *sql.Tx, group string, partition int, consumer string,
+	ttl time.Duration) (bool, error) {
+	now := c.clock.Now()
+	result, err := tx.Exec(c.dialect.Bind("UPDATE sub_leases SET consumer_id = ?, expiry = ? "+
+		"WHERE group_name = ? AND partition_id = ? AND (consumer_id = ? OR expiry <= ?)"),
+		consumer, now.Add(ttl).UnixNano(), group, partition, consumer, now.UnixNano())
+	if err != nil {
+		return false, err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows == 1 {
+		return err == nil, err
+	}
+	var owner string
+	err = tx.QueryRow(c.dialect.Bind("SELECT consumer_id FROM sub_leases WHERE group_name = ? AND partition_id = ?"),
+		group, partition).Scan(&owner)
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+	_, err = tx.Exec(c.dialect.Bind("INSERT INTO sub_leases (group_name, partition_id, consumer_id, expiry) "+
+		"VALUES (?, ?, ?, ?)"), group, partition, consumer, now.Add(ttl).UnixNano())
+	return err == nil, err
+}
+
+// contended will return false without error if the supplied acquire error was caused by another consumer leasing the
+// partition concurrently, reading back the owner of the partition.
+func (c *SQLCoordinator) contended(group string, partition int, consumer string, err error) (bool, error) {
+	var owner string
+	if c.db.QueryRow(c.dialect.Bind("SELECT consumer_id FROM sub_leases WHERE group_name = ? AND partition_id = ?"), group,
+		partition).Scan(&owner) == nil && owner != consumer {
+		return false, nil
+	}
+	return false, err
+}
+
+// Release will release the lease of the partition of the group, if owned by the consumer.
+func (c *SQLCoordinator) Release(group string, partition int, consumer string) error {
+	_, err := c.db.Exec(c.dialect.Bind("DELETE FROM sub_leases "+
+		"WHERE group_name = ? AND partition_id = ? AND consumer_id = ?"), group, partition, consumer)
+	return err
+}
+
+// Checkpoint will return the position of the last event processed by the group in the partition.
+func (c *SQLCoordinator) Checkpoint(group string, partition int) (int64, error) {
+	var position int64
+	err := c.db.QueryRow(c.dialect.Bind("SELECT position FROM sub_checkpoints WHERE group_name = ? AND partition_id = ?"),
+		group, partition).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return position, err
+}
+
+// SaveCheckpoint will save the position of the last event processed by the group in the partition. The consumer must
+// own the lease of the partition, which is locked until the checkpoint is saved.
+func (c *SQLCoordinator) SaveCheckpoint(group string, partition int, consumer string, position int64) error {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := c.saveCheckpoint(tx, group, partition, consumer, position); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (c *SQLCoordinator) saveCheckpoint(tx *sql.Tx, group string, partition int, consumer string,
+	position int64) error {
+	result, err := tx.Exec(c.dialect.Bind("UPDATE sub_leases SET expiry = expiry "+
+		"WHERE group_name = ? AND partition_id = ? AND consumer_id = ? AND expiry > ?"),
+		group, partition, consumer, c.clock.Now().UnixNano())
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows != 1 {
+		return leaseError{group, partition, consumer}
+	}
+	return c.upsert(tx, "UPDATE sub_checkpoints SET position = ? WHERE group_name = ? AND partition_id = ?",
+		"INSERT INTO sub_checkpoints (group_name, partition_id, position) VALUES (?, ?, ?)",
+		[]interface{}{position, group, partition}, []interface{}{group, partition, position})
+}
+
+// upsert will execute the supplied update statement, executing the insert one if no row was updated.
+func (c *SQLCoordinator) upsert(tx *sql.Tx, update, insert string, updateArgs, insertArgs []interface{}) error {
+	result, err := tx.Exec(c.dialect.Bind(update), updateArgs...)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil || rows > 0 {
+		return err
+	}
+	_, err = tx.Exec(c.dialect.Bind(insert), insertArgs...)
+	return err
+}
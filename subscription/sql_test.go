@@ -0,0 +1,16 @@
+package subscription_test
+
+import (
+	"testing"
+
+	"github.com/maurofran/kit/assert"
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/subscription"
+	. "github.com/maurofran/kit/testing"
+)
+
+func TestNewSQLCoordinator_NilDB(t *testing.T) {
+	_, err := subscription.NewSQLCoordinator(nil, subscription.SQLite, clock.System)
+
+	Assert(t, assert.IsArgumentError(err), "should return an argument error")
+}
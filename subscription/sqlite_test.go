@@ -0,0 +1,88 @@
+//go:build sqlite
+// +build sqlite
+
+// The SQL coordinator tests run against SQLite when the sqlite build tag is supplied, after adding the
+// modernc.org/sqlite driver, which is not a dependency of the module:
+//
+//	go get modernc.org/sqlite
+//	go test -tags sqlite ./subscription
+package subscription_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maurofran/kit/clock"
+	"github.com/maurofran/kit/eventstore"
+	"github.com/maurofran/kit/subscription"
+	. "github.com/maurofran/kit/testing"
+	_ "modernc.org/sqlite"
+)
+
+func aSQLCoordinator(t *testing.T, path string, clk clock.Clock) *subscription.SQLCoordinator {
+	db, err := sql.Open("sqlite", path)
+	Ok(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	c, err := subscription.NewSQLCoordinator(db, subscription.SQLite, clk)
+	Ok(t, err)
+	Ok(t, c.CreateSchema())
+	Ok(t, c.CreateSchema())
+	return c
+}
+
+func TestSQLCoordinator_Rebalance(t *testing.T) {
+	clk := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := aSQLCoordinator(t, filepath.Join(t.TempDir(), "groups.db"), clk)
+	s := eventstore.NewMemoryStore()
+	a := aConsumer(t, "a", s, c, new(recorder))
+	b := aConsumer(t, "b", s, c, new(recorder))
+	ctx := context.Background()
+
+	Ok(t, a.Poll(ctx))
+	Equals(t, []int{0, 1, 2, 3}, a.Partitions())
+	Ok(t, b.Poll(ctx))
+	Equals(t, []int{}, b.Partitions())
+	Ok(t, a.Poll(ctx))
+	Ok(t, b.Poll(ctx))
+	Equals(t, []int{0, 2}, a.Partitions())
+	Equals(t, []int{1, 3}, b.Partitions())
+
+	clk.Advance(11 * time.Second)
+	Ok(t, b.Poll(ctx))
+	Equals(t, []int{0, 1, 2, 3}, b.Partitions())
+}
+
+func TestSQLCoordinator_DurableCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "groups.db")
+	s := eventstore.NewMemoryStore()
+	appendEvents(s, 10)
+	a := aConsumer(t, "a", s, aSQLCoordinator(t, path, clock.System), new(recorder))
+	Ok(t, a.Poll(context.Background()))
+	Ok(t, a.Close())
+	appendEvents(s, 2)
+	r := new(recorder)
+	Ok(t, aConsumer(t, "c", s, aSQLCoordinator(t, path, clock.System), r).Poll(context.Background()))
+
+	Equals(t, map[int64]int{11: 1, 12: 1}, r.positions)
+}
+
+func TestSQLCoordinator_SaveCheckpointWithoutLease(t *testing.T) {
+	c := aSQLCoordinator(t, filepath.Join(t.TempDir(), "groups.db"), clock.System)
+	ok, err := c.Acquire("group", 0, "a", time.Minute)
+	Ok(t, err)
+	Assert(t, ok, "should acquire the lease")
+	ok, err = c.Acquire("group", 0, "b", time.Minute)
+	Ok(t, err)
+	Assert(t, !ok, "should not acquire a leased partition")
+
+	err = c.SaveCheckpoint("group", 0, "b", 1)
+	Assert(t, subscription.IsLeaseError(err), "should return a lease error")
+	Ok(t, c.SaveCheckpoint("group", 0, "a", 1))
+	checkpoint, err := c.Checkpoint("group", 0)
+	Ok(t, err)
+	Equals(t, int64(1), checkpoint)
+}